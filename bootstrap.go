@@ -0,0 +1,92 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	api "k8s.io/api/core/v1"
+)
+
+// Interval of checking if the node has been registered in bootstrap mode. The
+// interval is doubled after every attempt, up to the maximum interval, until
+// the timeout expires.
+const (
+	bootstrapPollInterval    = 5 * time.Second
+	bootstrapMaxPollInterval = time.Minute
+	bootstrapTimeout         = 30 * time.Minute
+)
+
+// kubeletNodeLabels formats labels in the format of the --node-labels flag of
+// kubelet
+func kubeletNodeLabels(labels Labels) string {
+	pairs := make([]string, 0, len(labels))
+	for _, name := range labelNames(labels) {
+		pairs = append(pairs, labelNs+name+"="+labels[name])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// writeBootstrapLabels writes labels into a file to be passed to kubelet, so
+// that the node is registered with the labels already in place
+func writeBootstrapLabels(path string, labels Labels) error {
+	// Write atomically so that kubelet never sees a partial file
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(kubeletNodeLabels(labels)+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %s", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write %s: %s", path, err)
+	}
+	return nil
+}
+
+// waitForNodeRegistration blocks until the node object of this node is
+// available in the API server, or the timeout expires. The labels set by
+// kubelet at registration are then recorded as labels managed by NFD, so that
+// they are removed once no longer discovered.
+func waitForNodeRegistration(helper APIHelpers, labels Labels, interval, timeout time.Duration) error {
+	stdoutLogger.Printf("waiting for the node to be registered")
+	deadline := time.Now().Add(timeout)
+	for {
+		cli, err := helper.GetClient()
+		if err == nil {
+			var node *api.Node
+			node, err = helper.GetNode(cli)
+			if err == nil {
+				if _, ok := node.Annotations[annotationNs+"feature-labels"]; ok {
+					return nil
+				}
+				helper.AddAnnotations(node, Annotations{"feature-labels": strings.Join(labelNames(labels), ",")})
+				return helper.UpdateNode(cli, node)
+			}
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			return fmt.Errorf("node not registered within %s: %s", timeout, err)
+		}
+		stderrLogger.Printf("node not registered yet, retrying in %s: %s", interval, err)
+		time.Sleep(interval)
+		if interval *= 2; interval > bootstrapMaxPollInterval {
+			interval = bootstrapMaxPollInterval
+		}
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+// RedfishSystem is a subset of the DMTF Redfish ComputerSystem schema, used for
+// exporting discovered features to hardware inventory systems. Properties
+// that have no standard counterpart are reported under the Oem property.
+type RedfishSystem struct {
+	OdataType      string                 `json:"@odata.type"`
+	Id             string                 `json:"Id"`
+	Name           string                 `json:"Name"`
+	TrustedModules []RedfishTrustedModule `json:"TrustedModules,omitempty"`
+	SecureBoot     *RedfishSecureBoot     `json:"SecureBoot,omitempty"`
+	Oem            RedfishOem             `json:"Oem"`
+}
+
+// RedfishTrustedModule describes a trusted platform module
+type RedfishTrustedModule struct {
+	InterfaceType string `json:"InterfaceType,omitempty"`
+}
+
+// RedfishSecureBoot describes the UEFI secure boot state
+type RedfishSecureBoot struct {
+	SecureBootEnable bool `json:"SecureBootEnable"`
+}
+
+// RedfishOem holds the NFD specific part of the exported document
+type RedfishOem struct {
+	NodeFeatureDiscovery RedfishNFD `json:"NodeFeatureDiscovery"`
+}
+
+// RedfishNFD holds all discovered features, grouped by feature source
+type RedfishNFD struct {
+	Version  string                       `json:"Version"`
+	Features map[string]map[string]string `json:"Features"`
+}
+
+// redfishSystem converts a set of feature labels to a Redfish ComputerSystem.
+// The labels are grouped by the given feature source names.
+func redfishSystem(nodeName string, labels Labels, sourceNames []string) RedfishSystem {
+	system := RedfishSystem{
+		OdataType: "#ComputerSystem.v1_5_0.ComputerSystem",
+		Id:        nodeName,
+		Name:      nodeName,
+		Oem: RedfishOem{NodeFeatureDiscovery: RedfishNFD{
+			Version:  version,
+			Features: map[string]map[string]string{}}},
+	}
+
+	for _, name := range labelNames(labels) {
+		value := labels[name]
+		// Labels from feature sources are of the form <source>-<feature>,
+		// labels from hooks are not prefixed and are reported under "local"
+		src, feature := "local", name
+		for _, s := range sourceNames {
+			if strings.HasPrefix(name, s+"-") {
+				src, feature = s, strings.TrimPrefix(name, s+"-")
+				break
+			}
+		}
+		if system.Oem.NodeFeatureDiscovery.Features[src] == nil {
+			system.Oem.NodeFeatureDiscovery.Features[src] = map[string]string{}
+		}
+		system.Oem.NodeFeatureDiscovery.Features[src][feature] = value
+	}
+
+	switch labels["security-tpm.version"] {
+	case "1.2":
+		system.TrustedModules = []RedfishTrustedModule{{InterfaceType: "TPM1_2"}}
+	case "2.0":
+		system.TrustedModules = []RedfishTrustedModule{{InterfaceType: "TPM2_0"}}
+	}
+	if _, ok := labels["security-uefi.enabled"]; ok {
+		system.SecureBoot = &RedfishSecureBoot{
+			SecureBootEnable: labels["security-uefi.secureboot"] == "true"}
+	}
+
+	return system
+}
+
+// exportFeatureLabels writes the feature labels discovered by the given
+// sources into a file in the Redfish compatible format.
+func exportFeatureLabels(path string, labels Labels, sources []source.FeatureSource) error {
+	nodeName := os.Getenv(NodeNameEnv)
+	if nodeName == "" {
+		var err error
+		if nodeName, err = os.Hostname(); err != nil {
+			return fmt.Errorf("failed to determine node name: %s", err)
+		}
+	}
+
+	sourceNames := make([]string, 0, len(sources))
+	for _, s := range sources {
+		sourceNames = append(sourceNames, s.Name())
+	}
+
+	data, err := json.MarshalIndent(redfishSystem(nodeName, labels, sourceNames), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode features: %s", err)
+	}
+
+	// Write atomically so that readers never see a partial file
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %s", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write %s: %s", path, err)
+	}
+	return nil
+}
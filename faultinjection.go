@@ -0,0 +1,105 @@
+// +build faultinjection
+
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+
+	api "k8s.io/api/core/v1"
+	k8sclient "k8s.io/client-go/kubernetes"
+)
+
+const (
+	// FaultErrorRateEnv is the environment variable specifying the
+	// probability (0.0-1.0) of an API call failing.
+	FaultErrorRateEnv = "NFD_FAULT_ERROR_RATE"
+
+	// FaultLatencyEnv is the environment variable specifying the latency
+	// added to each API call.
+	FaultLatencyEnv = "NFD_FAULT_LATENCY"
+)
+
+// faultyAPIHelpers wraps APIHelpers, injecting errors and latency into the
+// calls that contact the API server.
+type faultyAPIHelpers struct {
+	APIHelpers
+	errorRate float64
+	latency   time.Duration
+}
+
+// wrapAPIHelpers wraps the given helpers with fault injection, configured
+// from the environment.
+func wrapAPIHelpers(h APIHelpers) APIHelpers {
+	f := faultyAPIHelpers{APIHelpers: h}
+
+	if v := os.Getenv(FaultErrorRateEnv); v != "" {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			stderrLogger.Fatalf("invalid %s specified: %s", FaultErrorRateEnv, err)
+		}
+		f.errorRate = rate
+	}
+	if v := os.Getenv(FaultLatencyEnv); v != "" {
+		latency, err := time.ParseDuration(v)
+		if err != nil {
+			stderrLogger.Fatalf("invalid %s specified: %s", FaultLatencyEnv, err)
+		}
+		f.latency = latency
+	}
+	stderrLogger.Printf("WARNING: API fault injection enabled (error rate %v, latency %v)", f.errorRate, f.latency)
+
+	return f
+}
+
+// fault sleeps for the configured latency and returns an injected error
+// with the configured probability.
+func (f faultyAPIHelpers) fault(call string) error {
+	if f.latency > 0 {
+		time.Sleep(f.latency)
+	}
+	if f.errorRate > 0 && rand.Float64() < f.errorRate {
+		return fmt.Errorf("injected fault in %s", call)
+	}
+	return nil
+}
+
+func (f faultyAPIHelpers) GetClient() (*k8sclient.Clientset, error) {
+	if err := f.fault("GetClient"); err != nil {
+		return nil, err
+	}
+	return f.APIHelpers.GetClient()
+}
+
+func (f faultyAPIHelpers) GetNode(cli *k8sclient.Clientset) (*api.Node, error) {
+	if err := f.fault("GetNode"); err != nil {
+		return nil, err
+	}
+	return f.APIHelpers.GetNode(cli)
+}
+
+func (f faultyAPIHelpers) UpdateNode(cli *k8sclient.Clientset, n *api.Node) error {
+	if err := f.fault("UpdateNode"); err != nil {
+		return err
+	}
+	return f.APIHelpers.UpdateNode(cli, n)
+}
@@ -0,0 +1,64 @@
+// +build faultinjection
+
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	api "k8s.io/api/core/v1"
+	k8sclient "k8s.io/client-go/kubernetes"
+)
+
+func TestFaultyAPIHelpers(t *testing.T) {
+	Convey("When API fault injection is enabled", t, func() {
+		mockAPIHelper := new(MockAPIHelpers)
+		mockNode := &api.Node{}
+		var mockClient *k8sclient.Clientset
+
+		Convey("When the error rate is zero", func() {
+			os.Setenv(FaultErrorRateEnv, "0")
+			defer os.Unsetenv(FaultErrorRateEnv)
+			helper := wrapAPIHelpers(mockAPIHelper)
+			mockAPIHelper.On("GetClient").Return(mockClient, nil)
+			mockAPIHelper.On("UpdateNode", mockClient, mockNode).Return(nil)
+
+			Convey("Calls are passed through", func() {
+				_, err := helper.GetClient()
+				So(err, ShouldBeNil)
+				So(helper.UpdateNode(mockClient, mockNode), ShouldBeNil)
+			})
+		})
+
+		Convey("When the error rate is one", func() {
+			os.Setenv(FaultErrorRateEnv, "1")
+			defer os.Unsetenv(FaultErrorRateEnv)
+			helper := wrapAPIHelpers(mockAPIHelper)
+
+			Convey("Every call to the API server fails", func() {
+				_, err := helper.GetClient()
+				So(err, ShouldNotBeNil)
+				_, err = helper.GetNode(mockClient)
+				So(err, ShouldNotBeNil)
+				So(helper.UpdateNode(mockClient, mockNode), ShouldNotBeNil)
+			})
+		})
+	})
+}
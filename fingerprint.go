@@ -0,0 +1,62 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+)
+
+// fingerprintLabels is the allowlist of labels hashed into the hardware
+// fingerprint, per feature source. Only labels describing immutable hardware
+// properties are included, i.e. no counts or settings that may be changed at
+// runtime or by kernel parameters, such as online CPUs, SMT control, hugepages
+// or swap.
+var fingerprintLabels = map[string]*regexp.Regexp{
+	"cpu":    regexp.MustCompile(`^cpu-(vendor|family|model|stepping|implementer|part)$`),
+	"cpuid":  regexp.MustCompile(`^cpuid-`),
+	"memory": regexp.MustCompile(`^memory-size$`),
+	"pci":    regexp.MustCompile(`^pci-[0-9a-f_]+\.present$`),
+}
+
+// fingerprintExcludedLabels are labels of allowlisted sources that describe
+// settings, e.g. the SMT mode of POWER CPUs, or how the features were detected
+var fingerprintExcludedLabels = regexp.MustCompile(`^cpuid-(SMT_MODE|source)$`)
+
+// hardwareFingerprint returns a fingerprint of the node hardware, i.e. a
+// SHA-256 hash over the allowlisted labels of the given feature sources, or an
+// empty string if no sources are given. Labels are hashed in a stable order,
+// so the fingerprint only changes if the hardware identity of the node, i.e.
+// its CPU model and flags, memory size tier or PCI devices, changes. Sources
+// without allowlisted labels are ignored.
+func hardwareFingerprint(labels Labels, sources []string) string {
+	if len(sources) == 0 {
+		return ""
+	}
+
+	h := sha256.New()
+	for _, name := range labelNames(labels) {
+		for _, s := range sources {
+			if re, ok := fingerprintLabels[s]; ok && re.MatchString(name) && !fingerprintExcludedLabels.MatchString(name) {
+				fmt.Fprintf(h, "%s=%s\n", name, labels[name])
+				break
+			}
+		}
+	}
+	return fmt.Sprintf("sha256:%x", h.Sum(nil))
+}
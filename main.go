@@ -1,13 +1,17 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/docopt/docopt-go"
@@ -17,7 +21,9 @@ import (
 	"k8s.io/apimachinery/pkg/util/validation"
 	k8sclient "k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/accelerator"
 	"sigs.k8s.io/node-feature-discovery/source/cpu"
 	"sigs.k8s.io/node-feature-discovery/source/cpuid"
 	"sigs.k8s.io/node-feature-discovery/source/fake"
@@ -29,9 +35,13 @@ import (
 	"sigs.k8s.io/node-feature-discovery/source/panic_fake"
 	"sigs.k8s.io/node-feature-discovery/source/pci"
 	"sigs.k8s.io/node-feature-discovery/source/pstate"
+	"sigs.k8s.io/node-feature-discovery/source/rdma"
 	"sigs.k8s.io/node-feature-discovery/source/rdt"
+	"sigs.k8s.io/node-feature-discovery/source/runtime"
+	"sigs.k8s.io/node-feature-discovery/source/security"
 	"sigs.k8s.io/node-feature-discovery/source/storage"
 	"sigs.k8s.io/node-feature-discovery/source/system"
+	"sigs.k8s.io/node-feature-discovery/source/virtualization"
 )
 
 const (
@@ -41,11 +51,17 @@ const (
 	// Namespace is the prefix for all published labels.
 	labelNs = "feature.node.kubernetes.io/"
 
+	// Namespace of the labels published by old versions of NFD.
+	legacyLabelNs = "node.alpha.kubernetes-incubator.io/"
+
 	// Namespace is the prefix for all published labels.
 	annotationNs = "nfd.node.kubernetes.io/"
 
 	// NodeNameEnv is the environment variable that contains this node's name.
 	NodeNameEnv = "NODE_NAME"
+
+	// Maximum number of node updates waiting to be published
+	publishQueueSize = 4
 )
 
 var (
@@ -61,9 +77,14 @@ var (
 // Global config
 type NFDConfig struct {
 	Sources struct {
-		Kernel *kernel.NFDConfig `json:"kernel,omitempty"`
-		Pci    *pci.NFDConfig    `json:"pci,omitempty"`
+		Accelerator *accelerator.NFDConfig `json:"accelerator,omitempty"`
+		Cpuid       *cpuid.NFDConfig       `json:"cpuid,omitempty"`
+		Fake        *fake.NFDConfig        `json:"fake,omitempty"`
+		Kernel      *kernel.NFDConfig      `json:"kernel,omitempty"`
+		Memory      *memory.NFDConfig      `json:"memory,omitempty"`
+		Pci         *pci.NFDConfig         `json:"pci,omitempty"`
 	} `json:"sources,omitempty"`
+	NodeOverrides []NodeOverride `json:"nodeOverrides,omitempty"`
 }
 
 var config = NFDConfig{}
@@ -74,6 +95,10 @@ type Labels map[string]string
 // Annotations are used for NFD-related node metadata
 type Annotations map[string]string
 
+// SourceTimestamps hold the time of the last successful discovery of each
+// feature source.
+type SourceTimestamps map[string]time.Time
+
 // APIHelpers represents a set of API helpers for Kubernetes
 type APIHelpers interface {
 	// GetClient returns a client
@@ -95,6 +120,10 @@ type APIHelpers interface {
 	// API server using the client library.
 	AddLabels(*api.Node, Labels)
 
+	// AddLegacyLabels adds NFD labels in the format of old NFD versions,
+	// and the legacy version label, to the node object.
+	AddLegacyLabels(*api.Node, Labels)
+
 	// Add annotations
 	AddAnnotations(*api.Node, Annotations)
 
@@ -104,13 +133,25 @@ type APIHelpers interface {
 
 // Command line arguments
 type Args struct {
-	labelWhiteList string
-	configFile     string
-	noPublish      bool
-	options        string
-	oneshot        bool
-	sleepInterval  time.Duration
-	sources        []string
+	labelWhiteList     string
+	bootstrapFile      string
+	configFile         string
+	exportFile         string
+	kubeconfig         string
+	fingerprintSources []string
+	labelPriority      string
+	legacyLabels       bool
+	maxLabels          int
+	metricsAddress     string
+	noNetwork          bool
+	noPublish          bool
+	options            string
+	oneshot            bool
+	priorityLabels     string
+	sleepInterval      time.Duration
+	sources            []string
+	unknownSources     string
+	updateDelay        time.Duration
 }
 
 func main() {
@@ -129,25 +170,120 @@ func main() {
 		stderrLogger.Print(err)
 	}
 
+	helper := wrapAPIHelpers(k8sHelpers{kubeconfig: args.kubeconfig})
+
+	// The runtime source reads the container runtime from the node status
+	runtime.NodeRuntimeVersion = func() (string, error) {
+		return getContainerRuntimeVersion(helper)
+	}
+
+	// Apply the configuration overrides specific to this node
+	extraSources, err := configureNodeOverrides(helper, args.options)
+	if err != nil {
+		stderrLogger.Fatalf("error occurred while applying node overrides: %s", err.Error())
+	}
+
 	// Configure the parameters for feature discovery.
-	enabledSources, labelWhiteList, err := configureParameters(args.sources, args.labelWhiteList)
+	enabledSources, labelWhiteList, err := configureParameters(append(args.sources, extraSources...), args.labelWhiteList, args.unknownSources)
 	if err != nil {
 		stderrLogger.Fatalf("error occurred while configuring parameters: %s", err.Error())
 	}
+	if args.noNetwork {
+		enabledSources = disableNetworkSources(enabledSources)
+	}
+	enabledSources = orderSources(enabledSources)
 
-	helper := APIHelpers(k8sHelpers{})
+	quota, err := newLabelQuota(args.maxLabels, args.labelPriority)
+	if err != nil {
+		stderrLogger.Fatalf("error occurred while configuring label priorities: %s", err.Error())
+	}
 
-	for {
-		// Get the set of feature labels.
-		labels := createFeatureLabels(enabledSources, labelWhiteList)
+	throttle, err := newUpdateThrottle(args.updateDelay, args.priorityLabels)
+	if err != nil {
+		stderrLogger.Fatalf("error occurred while configuring update priorities: %s", err.Error())
+	}
+
+	timestamps := SourceTimestamps{}
+
+	// Publish node updates in the background so that slow API server writes
+	// do not delay the next discovery pass
+	publisher := newAsyncPublisher(publishQueueSize, func(u nodeUpdate) error {
+		return updateNodeWithFeatureLabels(helper, args.noPublish, args.legacyLabels, u)
+	}, func(u nodeUpdate) {
+		// Only count updates that reached the API server, so that dropped
+		// or failed updates are retried on the next discovery pass
+		throttle.updated(u.labels, time.Now())
+	})
+
+	if args.metricsAddress != "" {
+		// The expvar package registers its handler at /debug/vars
+		go func() {
+			err := http.ListenAndServe(args.metricsAddress, nil)
+			stderrLogger.Printf("ERROR: metrics endpoint failed: %s", err.Error())
+		}()
+	}
+
+	lastBootID := ""
 
-		// Update the node with the feature labels.
-		err = updateNodeWithFeatureLabels(helper, args.noPublish, labels)
+	// Label the node already at registration, through kubelet
+	if args.bootstrapFile != "" {
+		labels, _ := quota.apply(createFeatureLabels(enabledSources, labelWhiteList, timestamps))
+		if err := writeBootstrapLabels(args.bootstrapFile, labels); err != nil {
+			stderrLogger.Fatalf("failed to write bootstrap labels: %s", err.Error())
+		}
+		if !args.noPublish {
+			if err := waitForNodeRegistration(helper, labels, bootstrapPollInterval, bootstrapTimeout); err != nil {
+				stderrLogger.Fatalf("failed to update node with bootstrap labels: %s", err.Error())
+			}
+		}
+	}
+
+	for {
+		// Features may change across reboots, e.g. because of BIOS changes
+		// or kernel upgrades, so never defer the first update after one
+		bootID, err := getBootID()
 		if err != nil {
-			stderrLogger.Fatalf("error occurred while updating node with feature labels: %s", err.Error())
+			stderrLogger.Printf("failed to read boot ID: %s", err.Error())
+		} else if bootID != lastBootID {
+			if lastBootID != "" {
+				stdoutLogger.Printf("node rebooted (boot ID %s), resyncing all labels", bootID)
+			}
+			throttle.reset()
+			lastBootID = bootID
+		}
+
+		// Get the set of feature labels.
+		labels := createFeatureLabels(enabledSources, labelWhiteList, timestamps)
+		// Fingerprint all labels of the hardware sources, before any are
+		// dropped because of the label quota
+		fingerprint := hardwareFingerprint(labels, args.fingerprintSources)
+		// The label quota only applies to the node, all features are
+		// exported
+		if args.exportFile != "" {
+			if err := exportFeatureLabels(args.exportFile, labels, enabledSources); err != nil {
+				stderrLogger.Printf("failed to export features: %s", err.Error())
+			}
+		}
+		labels, dropped := quota.apply(labels)
+		drivers := createDriverManifest(enabledSources)
+
+		// Update the node with the feature labels, unless the update is
+		// coalesced with a later one.
+		if throttle.shouldUpdate(labels, time.Now()) {
+			// Discovery keeps updating the timestamps, publish a copy
+			ts := SourceTimestamps{}
+			for k, v := range timestamps {
+				ts[k] = v
+			}
+			publisher.enqueue(nodeUpdate{labels: labels, timestamps: ts, drivers: drivers, dropped: dropped, fingerprint: fingerprint, bootID: bootID})
+		} else {
+			stdoutLogger.Printf("no high-priority label changes, deferring node update")
 		}
 
 		if args.oneshot {
+			if err := publisher.stop(); err != nil {
+				stderrLogger.Fatalf("failed to update node with feature labels: %s", err.Error())
+			}
 			break
 		}
 
@@ -168,7 +304,12 @@ func argsParse(argv []string) (args Args) {
   Usage:
   %s [--no-publish] [--sources=<sources>] [--label-whitelist=<pattern>]
      [--oneshot | --sleep-interval=<seconds>] [--config=<path>]
-     [--options=<config>]
+     [--options=<config>] [--update-delay=<seconds>]
+     [--priority-labels=<pattern>] [--no-network] [--export=<path>]
+     [--legacy-labels] [--max-labels=<count>]
+     [--label-priority=<patterns>] [--fingerprint-sources=<sources>]
+     [--unknown-sources=<action>] [--bootstrap=<path>]
+     [--kubeconfig=<path>] [--metrics-address=<address>]
   %s -h | --help
   %s --version
 
@@ -183,15 +324,70 @@ func argsParse(argv []string) (args Args) {
                               will override settings read from the config file.
                               [Default: ]
   --sources=<sources>         Comma separated list of feature sources.
-                              [Default: cpu,cpuid,iommu,kernel,local,memory,network,pci,pstate,rdt,storage,system]
+                              [Default: accelerator,cpu,cpuid,iommu,kernel,local,memory,network,pci,pstate,rdma,rdt,security,storage,system,virtualization]
+  --unknown-sources=<action>  Action to take on unknown feature source names:
+                              'error' to exit, 'warn' to log a warning or
+                              'ignore'. [Default: warn]
   --no-publish                Do not publish discovered features to the
                               cluster-local Kubernetes API server.
+  --export=<path>             Also write discovered features into a file, in a
+                              JSON format compatible with the DMTF Redfish
+                              ComputerSystem schema. [Default: ]
+  --bootstrap=<path>          Bootstrap mode for running before the node has
+                              been registered, e.g. as a static pod. Write
+                              the discovered labels into a file, in the format
+                              of the kubelet node-labels flag, and wait for
+                              the node to be registered before publishing the
+                              labels to the Kubernetes API server. [Default: ]
+  --kubeconfig=<path>         Kubeconfig file for accessing the Kubernetes API
+                              server, e.g. the credentials of kubelet when run
+                              as a static pod. Empty value implies the
+                              in-cluster configuration of the pod.
+                              [Default: ]
+  --no-network                Disable all feature sources that access the
+                              network, for air-gapped environments. None of the
+                              default sources access the network.
+  --legacy-labels             Also publish labels in the legacy format of old
+                              NFD versions, i.e.
+                              node.alpha.kubernetes-incubator.io/nfd-<label>,
+                              for consumers that have not been migrated yet.
+                              Legacy labels are removed if not set.
   --label-whitelist=<pattern> Regular expression to filter label names to
                               publish to the Kubernetes API server. [Default: ]
+  --max-labels=<count>        Maximum number of labels to publish. If more
+                              features are discovered, the labels with the
+                              lowest priority are dropped. Zero implies no
+                              limit. [Default: 0]
+  --label-priority=<patterns> Comma separated list of regular expressions
+                              matching label names, in decreasing order of
+                              priority, used for dropping labels over the
+                              maximum number of labels. A single '*' stands
+                              for all labels not matching any of the patterns.
+                              [Default: *,^cpuid-]
+  --fingerprint-sources=<sources>
+                              Comma separated list of feature sources whose
+                              hardware labels are hashed into a hardware
+                              fingerprint, published as an annotation. The
+                              fingerprint is stable across reinstallations of
+                              the node, and changes if its hardware is swapped.
+                              Empty value disables the fingerprint.
+                              [Default: cpu,cpuid,memory,pci]
+  --metrics-address=<address> Serve publisher metrics (pending, published,
+                              failed and dropped node updates) in the expvar
+                              format at http://<address>/debug/vars. Empty
+                              value disables the metrics endpoint. [Default: ]
   --oneshot                   Label once and exit.
   --sleep-interval=<seconds>  Time to sleep between re-labeling. Non-positive
                               value implies no re-labeling (i.e. infinite
-                              sleep). [Default: 60s]`,
+                              sleep). [Default: 60s]
+  --update-delay=<seconds>    Minimum time between node updates. Routine label
+                              changes discovered in between are coalesced into
+                              the next update. Non-positive value implies that
+                              the node is updated on every discovery pass.
+                              [Default: 0s]
+  --priority-labels=<pattern> Regular expression matching high-priority label
+                              names. Changes in these labels bypass the update
+                              delay and are published immediately. [Default: ]`,
 		ProgramName,
 		ProgramName,
 		ProgramName,
@@ -203,12 +399,29 @@ func argsParse(argv []string) (args Args) {
 
 	// Parse argument values as usable types.
 	var err error
+	args.bootstrapFile = arguments["--bootstrap"].(string)
 	args.configFile = arguments["--config"].(string)
+	args.exportFile = arguments["--export"].(string)
+	args.kubeconfig = arguments["--kubeconfig"].(string)
+	if s := arguments["--fingerprint-sources"].(string); s != "" {
+		args.fingerprintSources = strings.Split(s, ",")
+	}
+	for _, s := range args.fingerprintSources {
+		if _, ok := fingerprintLabels[s]; !ok {
+			stderrLogger.Printf("WARNING: no hardware labels of source %q are included in the fingerprint", s)
+		}
+	}
+	args.legacyLabels = arguments["--legacy-labels"].(bool)
+	args.metricsAddress = arguments["--metrics-address"].(string)
+	args.labelPriority = arguments["--label-priority"].(string)
+	args.noNetwork = arguments["--no-network"].(bool)
 	args.noPublish = arguments["--no-publish"].(bool)
 	args.options = arguments["--options"].(string)
 	args.sources = strings.Split(arguments["--sources"].(string), ",")
+	args.unknownSources = arguments["--unknown-sources"].(string)
 	args.labelWhiteList = arguments["--label-whitelist"].(string)
 	args.oneshot = arguments["--oneshot"].(bool)
+	args.priorityLabels = arguments["--priority-labels"].(string)
 	args.sleepInterval, err = time.ParseDuration(arguments["--sleep-interval"].(string))
 
 	// Check that sleep interval has a sane value
@@ -220,12 +433,32 @@ func argsParse(argv []string) (args Args) {
 		args.sleepInterval = time.Second
 	}
 
+	args.updateDelay, err = time.ParseDuration(arguments["--update-delay"].(string))
+	if err != nil {
+		stderrLogger.Fatalf("invalid --update-delay specified: %s", err.Error())
+	}
+
+	switch args.unknownSources {
+	case "error", "warn", "ignore":
+	default:
+		stderrLogger.Fatalf("invalid --unknown-sources specified: %s", args.unknownSources)
+	}
+
+	args.maxLabels, err = strconv.Atoi(arguments["--max-labels"].(string))
+	if err != nil {
+		stderrLogger.Fatalf("invalid --max-labels specified: %s", err.Error())
+	}
+
 	return args
 }
 
 // Parse configuration options
 func configParse(filepath string, overrides string) error {
+	config.Sources.Accelerator = &accelerator.Config
+	config.Sources.Cpuid = &cpuid.Config
+	config.Sources.Fake = &fake.Config
 	config.Sources.Kernel = &kernel.Config
+	config.Sources.Memory = &memory.Config
 	config.Sources.Pci = &pci.Config
 
 	data, err := ioutil.ReadFile(filepath)
@@ -249,8 +482,9 @@ func configParse(filepath string, overrides string) error {
 }
 
 // configureParameters returns all the variables required to perform feature
-// discovery based on command line arguments.
-func configureParameters(sourcesWhiteList []string, labelWhiteListStr string) (enabledSources []source.FeatureSource, labelWhiteList *regexp.Regexp, err error) {
+// discovery based on command line arguments. Unknown source names are handled
+// according to unknownSources, i.e. "error", "warn" or "ignore".
+func configureParameters(sourcesWhiteList []string, labelWhiteListStr string, unknownSources string) (enabledSources []source.FeatureSource, labelWhiteList *regexp.Regexp, err error) {
 	// A map for lookup
 	sourcesWhiteListMap := map[string]struct{}{}
 	for _, s := range sourcesWhiteList {
@@ -259,6 +493,7 @@ func configureParameters(sourcesWhiteList []string, labelWhiteListStr string) (e
 
 	// Configure feature sources.
 	allSources := []source.FeatureSource{
+		accelerator.Source{},
 		cpu.Source{},
 		cpuid.Source{},
 		fake.Source{},
@@ -269,10 +504,14 @@ func configureParameters(sourcesWhiteList []string, labelWhiteListStr string) (e
 		panic_fake.Source{},
 		pci.Source{},
 		pstate.Source{},
+		rdma.Source{},
 		rdt.Source{},
+		runtime.Source{},
+		security.Source{},
 		storage.Source{},
 		system.Source{},
-		// local needs to be the last source so that it is able to override
+		virtualization.Source{},
+		// local has the highest priority so that it is able to override
 		// labels from other sources
 		local.Source{},
 	}
@@ -281,6 +520,23 @@ func configureParameters(sourcesWhiteList []string, labelWhiteListStr string) (e
 	for _, s := range allSources {
 		if _, enabled := sourcesWhiteListMap[s.Name()]; enabled {
 			enabledSources = append(enabledSources, s)
+			delete(sourcesWhiteListMap, s.Name())
+		}
+	}
+
+	// Catch typos in source names
+	delete(sourcesWhiteListMap, "")
+	if len(sourcesWhiteListMap) > 0 {
+		unknown := []string{}
+		for name := range sourcesWhiteListMap {
+			unknown = append(unknown, name)
+		}
+		sort.Strings(unknown)
+		switch unknownSources {
+		case "error":
+			return nil, nil, fmt.Errorf("unknown feature sources: %s", strings.Join(unknown, ", "))
+		case "warn":
+			stderrLogger.Printf("WARNING: ignoring unknown feature sources: %s", strings.Join(unknown, ", "))
 		}
 	}
 
@@ -294,48 +550,137 @@ func configureParameters(sourcesWhiteList []string, labelWhiteListStr string) (e
 	return enabledSources, labelWhiteList, nil
 }
 
+// disableNetworkSources returns the given sources, excluding the ones that
+// access the network.
+func disableNetworkSources(sources []source.FeatureSource) []source.FeatureSource {
+	filtered := []source.FeatureSource{}
+	for _, s := range sources {
+		if ns, ok := s.(source.NetworkFeatureSource); ok && ns.UsesNetwork() {
+			stderrLogger.Printf("network access disabled, skipping source [%s]", s.Name())
+			continue
+		}
+		filtered = append(filtered, s)
+	}
+	return filtered
+}
+
 // createFeatureLabels returns the set of feature labels from the enabled
-// sources and the whitelist argument.
-func createFeatureLabels(sources []source.FeatureSource, labelWhiteList *regexp.Regexp) (labels Labels) {
+// sources and the whitelist argument. The time of successful discovery is
+// recorded in timestamps, if non-nil. Sources are run in parallel, and their
+// labels are applied in the order of the sources, so that labels from sources
+// ordered later override the earlier ones.
+func createFeatureLabels(sources []source.FeatureSource, labelWhiteList *regexp.Regexp, timestamps SourceTimestamps) (labels Labels) {
 	labels = Labels{}
 
 	// Do feature discovery from all configured sources.
-	for _, source := range sources {
-		labelsFromSource, err := getFeatureLabels(source)
-		if err != nil {
-			stderrLogger.Printf("discovery failed for source [%s]: %s", source.Name(), err.Error())
+	type result struct {
+		labels Labels
+		err    error
+		time   time.Time
+	}
+	results := make([]result, len(sources))
+	var wg sync.WaitGroup
+	for i, s := range sources {
+		wg.Add(1)
+		go func(i int, s source.FeatureSource) {
+			defer wg.Done()
+			l, err := getFeatureLabels(s)
+			results[i] = result{labels: l, err: err, time: time.Now()}
+		}(i, s)
+	}
+	wg.Wait()
+
+	for i, s := range sources {
+		if results[i].err != nil {
+			stderrLogger.Printf("discovery failed for source [%s]: %s", s.Name(), results[i].err.Error())
 			stderrLogger.Printf("continuing ...")
 			continue
 		}
-
-		for name, value := range labelsFromSource {
-			// Log discovered feature.
-			stdoutLogger.Printf("%s = %s", name, value)
-			// Skip if label doesn't match labelWhiteList
-			if !labelWhiteList.Match([]byte(name)) {
-				stderrLogger.Printf("%s does not match the whitelist (%s) and will not be published.", name, labelWhiteList.String())
-				continue
-			}
-			labels[name] = value
+		if timestamps != nil {
+			timestamps[s.Name()] = results[i].time
 		}
+		addSourceLabels(labels, results[i].labels, labelWhiteList)
 	}
 	return labels
 }
 
+// addSourceLabels adds the labels discovered by one source to the set of
+// labels, skipping labels not matching the whitelist.
+func addSourceLabels(labels, labelsFromSource Labels, labelWhiteList *regexp.Regexp) {
+	for _, name := range labelNames(labelsFromSource) {
+		value := labelsFromSource[name]
+		// Log discovered feature.
+		stdoutLogger.Printf("%s = %s", name, value)
+		// Skip if label doesn't match labelWhiteList
+		if !labelWhiteList.Match([]byte(name)) {
+			stderrLogger.Printf("%s does not match the whitelist (%s) and will not be published.", name, labelWhiteList.String())
+			continue
+		}
+		labels[name] = value
+	}
+}
+
+// createDriverManifest returns the drivers reported by the enabled sources, or
+// nil if none of the sources reports drivers.
+func createDriverManifest(sources []source.FeatureSource) []source.Driver {
+	var drivers []source.Driver
+
+	for _, s := range sources {
+		ds, ok := s.(source.DriverSource)
+		if !ok {
+			continue
+		}
+		if drivers == nil {
+			drivers = []source.Driver{}
+		}
+		d, err := ds.Drivers()
+		if err != nil {
+			stderrLogger.Printf("failed to get drivers from source [%s]: %s", s.Name(), err.Error())
+			continue
+		}
+		drivers = append(drivers, d...)
+	}
+	return drivers
+}
+
 // updateNodeWithFeatureLabels updates the node with the feature labels, unless
-// disabled via --no-publish flag.
-func updateNodeWithFeatureLabels(helper APIHelpers, noPublish bool, labels Labels) error {
+// disabled via --no-publish flag. The driver manifest is advertised as an
+// annotation, if non-nil, as are the names of the labels dropped because of
+// the label quota and the boot ID of the node.
+func updateNodeWithFeatureLabels(helper APIHelpers, noPublish, legacyLabels bool, u nodeUpdate) error {
 	if !noPublish {
 		// Advertise NFD version and label names as annotations
-		keys := make([]string, 0, len(labels))
-		for k, _ := range labels {
-			keys = append(keys, k)
-		}
-		sort.Strings(keys)
 		annotations := Annotations{"version": version,
-			"feature-labels": strings.Join(keys, ",")}
+			"feature-labels": strings.Join(labelNames(u.labels), ",")}
+
+		// Advertise when each source was last successfully discovered so
+		// that missing features can be told apart from stale sources
+		for name, t := range u.timestamps {
+			annotations[name+".last-success"] = t.UTC().Format(time.RFC3339)
+		}
+
+		if u.drivers != nil {
+			manifest, err := json.Marshal(u.drivers)
+			if err != nil {
+				stderrLogger.Printf("failed to encode driver manifest: %s", err.Error())
+				return err
+			}
+			annotations["driver-manifest"] = string(manifest)
+		}
+
+		if len(u.dropped) > 0 {
+			annotations["dropped-labels"] = strings.Join(u.dropped, ",")
+		}
+
+		if u.fingerprint != "" {
+			annotations["hardware-fingerprint"] = u.fingerprint
+		}
 
-		err := advertiseFeatureLabels(helper, labels, annotations)
+		if u.bootID != "" {
+			annotations["boot-id"] = u.bootID
+		}
+
+		err := advertiseFeatureLabels(helper, u.labels, annotations, legacyLabels)
 		if err != nil {
 			stderrLogger.Printf("failed to advertise labels: %s", err.Error())
 			return err
@@ -344,6 +689,171 @@ func updateNodeWithFeatureLabels(helper APIHelpers, noPublish bool, labels Label
 	return nil
 }
 
+// getBootID returns the boot ID of the running kernel, which changes on every
+// reboot.
+func getBootID() (string, error) {
+	data, err := ioutil.ReadFile("/proc/sys/kernel/random/boot_id")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// labelNames returns the names of the given labels in sorted order so that
+// all output is stable between runs.
+func labelNames(labels Labels) []string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// labelQuota limits the number of published labels, dropping the labels with
+// the lowest priority first.
+type labelQuota struct {
+	max      int
+	patterns []*regexp.Regexp
+	// Priority of the labels not matching any of the patterns
+	defaultPriority int
+}
+
+// newLabelQuota creates a new labelQuota. The priority patterns are given as
+// a comma separated list, '*' denoting labels not matching any pattern.
+// Unmatched labels have the lowest priority if '*' is not in the list.
+func newLabelQuota(max int, priorityPatterns string) (*labelQuota, error) {
+	q := &labelQuota{max: max}
+	if priorityPatterns == "" {
+		return q, nil
+	}
+	q.defaultPriority = -1
+	for _, p := range strings.Split(priorityPatterns, ",") {
+		if p == "*" {
+			q.defaultPriority = len(q.patterns)
+			continue
+		}
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --label-priority pattern (%s): %s", p, err)
+		}
+		q.patterns = append(q.patterns, re)
+	}
+	if q.defaultPriority < 0 {
+		q.defaultPriority = len(q.patterns)
+	}
+	return q, nil
+}
+
+// priority returns the priority of a label, lower values meaning higher
+// priority.
+func (q *labelQuota) priority(name string) int {
+	for i, re := range q.patterns {
+		if re.MatchString(name) {
+			if i < q.defaultPriority {
+				return i
+			}
+			// Make room for the unmatched labels in between
+			return i + 1
+		}
+	}
+	return q.defaultPriority
+}
+
+// apply returns the labels that fit in the quota, and the sorted names of the
+// labels that were dropped.
+func (q *labelQuota) apply(labels Labels) (Labels, []string) {
+	if q.max <= 0 || len(labels) <= q.max {
+		return labels, nil
+	}
+
+	names := labelNames(labels)
+	sort.SliceStable(names, func(i, j int) bool {
+		return q.priority(names[i]) < q.priority(names[j])
+	})
+
+	kept := Labels{}
+	for _, name := range names[:q.max] {
+		kept[name] = labels[name]
+	}
+	dropped := names[q.max:]
+	sort.Strings(dropped)
+	for _, name := range dropped {
+		stderrLogger.Printf("label quota of %d exceeded, dropping %s", q.max, name)
+	}
+	return kept, dropped
+}
+
+// updateThrottle coalesces routine node updates so that the node object is
+// updated at most once per update delay. Changes in labels matching the
+// priority pattern are never delayed.
+type updateThrottle struct {
+	// Updates are recorded by the publisher goroutine
+	mu         sync.Mutex
+	delay      time.Duration
+	priority   *regexp.Regexp
+	published  Labels
+	lastUpdate time.Time
+}
+
+// newUpdateThrottle creates a new updateThrottle. An empty priority pattern
+// means that no labels are considered high-priority.
+func newUpdateThrottle(delay time.Duration, priorityPattern string) (*updateThrottle, error) {
+	t := &updateThrottle{delay: delay}
+	if priorityPattern != "" {
+		re, err := regexp.Compile(priorityPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --priority-labels (%s): %s", priorityPattern, err)
+		}
+		t.priority = re
+	}
+	return t, nil
+}
+
+// shouldUpdate returns true if the node should be updated with the given
+// labels at the given time.
+func (t *updateThrottle) shouldUpdate(labels Labels, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.delay <= 0 || t.published == nil || now.Sub(t.lastUpdate) >= t.delay {
+		return true
+	}
+	if t.priority == nil {
+		return false
+	}
+
+	// Check for added, changed or removed high-priority labels
+	for name, value := range labels {
+		if old, ok := t.published[name]; (!ok || old != value) && t.priority.MatchString(name) {
+			stdoutLogger.Printf("high-priority label %s changed, updating node immediately", name)
+			return true
+		}
+	}
+	for name := range t.published {
+		if _, ok := labels[name]; !ok && t.priority.MatchString(name) {
+			stdoutLogger.Printf("high-priority label %s removed, updating node immediately", name)
+			return true
+		}
+	}
+	return false
+}
+
+// reset forgets the previous update, so that the next update is not delayed.
+func (t *updateThrottle) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.published = nil
+}
+
+// updated records that the node was updated with the given labels.
+func (t *updateThrottle) updated(labels Labels, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.published = labels
+	t.lastUpdate = now
+}
+
 // getFeatureLabels returns node labels for features discovered by the
 // supplied source.
 func getFeatureLabels(source source.FeatureSource) (labels Labels, err error) {
@@ -359,7 +869,16 @@ func getFeatureLabels(source source.FeatureSource) (labels Labels, err error) {
 	if err != nil {
 		return nil, err
 	}
-	for k, v := range features {
+
+	// Process features in a stable order to get deterministic log output
+	names := make([]string, 0, len(features))
+	for k := range features {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, k := range names {
+		v := features[k]
 		// Validate label name
 		prefix := source.Name() + "-"
 		switch source.(type) {
@@ -391,8 +910,9 @@ func getFeatureLabels(source source.FeatureSource) (labels Labels, err error) {
 }
 
 // advertiseFeatureLabels advertises the feature labels to a Kubernetes node
-// via the API server.
-func advertiseFeatureLabels(helper APIHelpers, labels Labels, annotations Annotations) error {
+// via the API server. The labels are also advertised in the legacy format if
+// legacyLabels is set.
+func advertiseFeatureLabels(helper APIHelpers, labels Labels, annotations Annotations, legacyLabels bool) error {
 	cli, err := helper.GetClient()
 	if err != nil {
 		stderrLogger.Printf("can't get kubernetes client: %s", err.Error())
@@ -413,11 +933,14 @@ func advertiseFeatureLabels(helper APIHelpers, labels Labels, annotations Annota
 	}
 
 	// Also, remove all labels with the old prefix, and the old version label
-	helper.RemoveLabelsWithPrefix(node, "node.alpha.kubernetes-incubator.io/nfd")
-	helper.RemoveLabelsWithPrefix(node, "node.alpha.kubernetes-incubator.io/node-feature-discovery")
+	helper.RemoveLabelsWithPrefix(node, legacyLabelNs+"nfd")
+	helper.RemoveLabelsWithPrefix(node, legacyLabelNs+"node-feature-discovery")
 
 	// Add labels to the node object.
 	helper.AddLabels(node, labels)
+	if legacyLabels {
+		helper.AddLegacyLabels(node, labels)
+	}
 
 	// Add annotations
 	helper.AddAnnotations(node, annotations)
@@ -433,11 +956,20 @@ func advertiseFeatureLabels(helper APIHelpers, labels Labels, annotations Annota
 }
 
 // Implements main.APIHelpers
-type k8sHelpers struct{}
+type k8sHelpers struct {
+	// Kubeconfig file to use instead of the in-cluster configuration
+	kubeconfig string
+}
 
 func (h k8sHelpers) GetClient() (*k8sclient.Clientset, error) {
-	// Set up an in-cluster K8S client.
-	config, err := restclient.InClusterConfig()
+	// Set up an in-cluster K8S client, unless a kubeconfig is given.
+	var config *restclient.Config
+	var err error
+	if h.kubeconfig != "" {
+		config, err = clientcmd.BuildConfigFromFlags("", h.kubeconfig)
+	} else {
+		config, err = restclient.InClusterConfig()
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -486,6 +1018,26 @@ func (h k8sHelpers) AddLabels(n *api.Node, labels Labels) {
 	}
 }
 
+// AddLegacyLabels adds the feature labels, and the version label, to the node
+// object in the format used by old versions of NFD, i.e.
+// node.alpha.kubernetes-incubator.io/nfd-<source>-<feature>. Labels whose
+// name becomes invalid with the longer prefix, i.e. exceeds 63 characters,
+// are skipped.
+func (h k8sHelpers) AddLegacyLabels(n *api.Node, labels Labels) {
+	if n.Labels == nil {
+		n.Labels = map[string]string{}
+	}
+	for k, v := range labels {
+		name := legacyLabelNs + "nfd-" + k
+		if errs := validation.IsQualifiedName(name); len(errs) > 0 {
+			stderrLogger.Printf("Ignoring invalid legacy label name '%s': %s", name, errs)
+			continue
+		}
+		n.Labels[name] = v
+	}
+	n.Labels[legacyLabelNs+"node-feature-discovery.version"] = version
+}
+
 // Add Annotations to the Node object
 func (h k8sHelpers) AddAnnotations(n *api.Node, annotations Annotations) {
 	for k, v := range annotations {
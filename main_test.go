@@ -9,14 +9,18 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ghodss/yaml"
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/vektra/errors"
 	api "k8s.io/api/core/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sclient "k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/accelerator"
 	"sigs.k8s.io/node-feature-discovery/source/fake"
+	"sigs.k8s.io/node-feature-discovery/source/kernel"
 	"sigs.k8s.io/node-feature-discovery/source/panic_fake"
+	"sigs.k8s.io/node-feature-discovery/source/pci"
 )
 
 func TestDiscoveryWithMockSources(t *testing.T) {
@@ -79,18 +83,109 @@ func TestDiscoveryWithMockSources(t *testing.T) {
 			mockAPIHelper.On("AddAnnotations", mockNode, fakeAnnotations).Return().Once()
 			mockAPIHelper.On("UpdateNode", mockClient, mockNode).Return(nil).Once()
 			noPublish := false
-			err := updateNodeWithFeatureLabels(testHelper, noPublish, fakeFeatureLabels)
+			err := updateNodeWithFeatureLabels(testHelper, noPublish, false, nodeUpdate{labels: fakeFeatureLabels})
 
 			Convey("Error is nil", func() {
 				So(err, ShouldBeNil)
 			})
 		})
 
+		Convey("When I successfully update the node with feature labels and legacy labels", func() {
+			mockAPIHelper.On("GetClient").Return(mockClient, nil)
+			mockAPIHelper.On("GetNode", mockClient).Return(mockNode, nil).Once()
+			mockAPIHelper.On("AddLabels", mockNode, fakeFeatureLabels).Return().Once()
+			mockAPIHelper.On("AddLegacyLabels", mockNode, fakeFeatureLabels).Return().Once()
+			mockAPIHelper.On("RemoveLabelsWithPrefix", mockNode, "node.alpha.kubernetes-incubator.io/nfd").Return().Once()
+			mockAPIHelper.On("RemoveLabelsWithPrefix", mockNode, "node.alpha.kubernetes-incubator.io/node-feature-discovery").Return().Once()
+			mockAPIHelper.On("AddAnnotations", mockNode, fakeAnnotations).Return().Once()
+			mockAPIHelper.On("UpdateNode", mockClient, mockNode).Return(nil).Once()
+			err := updateNodeWithFeatureLabels(testHelper, false, true, nodeUpdate{labels: fakeFeatureLabels})
+
+			Convey("Error is nil", func() {
+				So(err, ShouldBeNil)
+			})
+			Convey("Legacy labels are added", func() {
+				mockAPIHelper.AssertCalled(t, "AddLegacyLabels", mockNode, fakeFeatureLabels)
+			})
+		})
+
+		Convey("When I successfully update the node with feature labels, source timestamps and boot ID", func() {
+			timestamps := SourceTimestamps{fakeFeatureSourceName: time.Date(2019, 3, 14, 12, 0, 0, 0, time.UTC)}
+			expectedAnnotations := Annotations{}
+			for k, v := range fakeAnnotations {
+				expectedAnnotations[k] = v
+			}
+			expectedAnnotations[fakeFeatureSourceName+".last-success"] = "2019-03-14T12:00:00Z"
+			expectedAnnotations["boot-id"] = "fake-boot-id"
+			mockAPIHelper.On("GetClient").Return(mockClient, nil)
+			mockAPIHelper.On("GetNode", mockClient).Return(mockNode, nil).Once()
+			mockAPIHelper.On("AddLabels", mockNode, fakeFeatureLabels).Return().Once()
+			mockAPIHelper.On("RemoveLabelsWithPrefix", mockNode, "node.alpha.kubernetes-incubator.io/nfd").Return().Once()
+			mockAPIHelper.On("RemoveLabelsWithPrefix", mockNode, "node.alpha.kubernetes-incubator.io/node-feature-discovery").Return().Once()
+			mockAPIHelper.On("AddAnnotations", mockNode, expectedAnnotations).Return().Once()
+			mockAPIHelper.On("UpdateNode", mockClient, mockNode).Return(nil).Once()
+			err := updateNodeWithFeatureLabels(testHelper, false, false, nodeUpdate{labels: fakeFeatureLabels, timestamps: timestamps, bootID: "fake-boot-id"})
+
+			Convey("Error is nil", func() {
+				So(err, ShouldBeNil)
+			})
+			Convey("Source timestamps and boot ID are advertised as annotations", func() {
+				mockAPIHelper.AssertExpectations(t)
+			})
+		})
+
+		Convey("When I successfully update the node with feature labels and driver manifest", func() {
+			drivers := []source.Driver{{Device: "0000:00:01.0", Driver: "fakedriver", Firmware: "2.0"}}
+			expectedAnnotations := Annotations{}
+			for k, v := range fakeAnnotations {
+				expectedAnnotations[k] = v
+			}
+			expectedAnnotations["driver-manifest"] = `[{"device":"0000:00:01.0","driver":"fakedriver","firmware":"2.0"}]`
+			mockAPIHelper.On("GetClient").Return(mockClient, nil)
+			mockAPIHelper.On("GetNode", mockClient).Return(mockNode, nil).Once()
+			mockAPIHelper.On("AddLabels", mockNode, fakeFeatureLabels).Return().Once()
+			mockAPIHelper.On("RemoveLabelsWithPrefix", mockNode, "node.alpha.kubernetes-incubator.io/nfd").Return().Once()
+			mockAPIHelper.On("RemoveLabelsWithPrefix", mockNode, "node.alpha.kubernetes-incubator.io/node-feature-discovery").Return().Once()
+			mockAPIHelper.On("AddAnnotations", mockNode, expectedAnnotations).Return().Once()
+			mockAPIHelper.On("UpdateNode", mockClient, mockNode).Return(nil).Once()
+			err := updateNodeWithFeatureLabels(testHelper, false, false, nodeUpdate{labels: fakeFeatureLabels, drivers: drivers})
+
+			Convey("Error is nil", func() {
+				So(err, ShouldBeNil)
+			})
+			Convey("Driver manifest is advertised as an annotation", func() {
+				mockAPIHelper.AssertExpectations(t)
+			})
+		})
+
+		Convey("When I successfully update the node with feature labels and hardware fingerprint", func() {
+			expectedAnnotations := Annotations{}
+			for k, v := range fakeAnnotations {
+				expectedAnnotations[k] = v
+			}
+			expectedAnnotations["hardware-fingerprint"] = "sha256:fake"
+			mockAPIHelper.On("GetClient").Return(mockClient, nil)
+			mockAPIHelper.On("GetNode", mockClient).Return(mockNode, nil).Once()
+			mockAPIHelper.On("AddLabels", mockNode, fakeFeatureLabels).Return().Once()
+			mockAPIHelper.On("RemoveLabelsWithPrefix", mockNode, "node.alpha.kubernetes-incubator.io/nfd").Return().Once()
+			mockAPIHelper.On("RemoveLabelsWithPrefix", mockNode, "node.alpha.kubernetes-incubator.io/node-feature-discovery").Return().Once()
+			mockAPIHelper.On("AddAnnotations", mockNode, expectedAnnotations).Return().Once()
+			mockAPIHelper.On("UpdateNode", mockClient, mockNode).Return(nil).Once()
+			err := updateNodeWithFeatureLabels(testHelper, false, false, nodeUpdate{labels: fakeFeatureLabels, fingerprint: "sha256:fake"})
+
+			Convey("Error is nil", func() {
+				So(err, ShouldBeNil)
+			})
+			Convey("Hardware fingerprint is advertised as an annotation", func() {
+				mockAPIHelper.AssertExpectations(t)
+			})
+		})
+
 		Convey("When I fail to update the node with feature labels", func() {
 			expectedError := errors.New("fake error")
 			mockAPIHelper.On("GetClient").Return(nil, expectedError)
 			noPublish := false
-			err := updateNodeWithFeatureLabels(testHelper, noPublish, fakeFeatureLabels)
+			err := updateNodeWithFeatureLabels(testHelper, noPublish, false, nodeUpdate{labels: fakeFeatureLabels})
 
 			Convey("Error is produced", func() {
 				So(err, ShouldEqual, expectedError)
@@ -100,7 +195,7 @@ func TestDiscoveryWithMockSources(t *testing.T) {
 		Convey("When I fail to get a mock client while advertising feature labels", func() {
 			expectedError := errors.New("fake error")
 			mockAPIHelper.On("GetClient").Return(nil, expectedError)
-			err := advertiseFeatureLabels(testHelper, fakeFeatureLabels, fakeAnnotations)
+			err := advertiseFeatureLabels(testHelper, fakeFeatureLabels, fakeAnnotations, false)
 
 			Convey("Error is produced", func() {
 				So(err, ShouldEqual, expectedError)
@@ -111,7 +206,7 @@ func TestDiscoveryWithMockSources(t *testing.T) {
 			expectedError := errors.New("fake error")
 			mockAPIHelper.On("GetClient").Return(mockClient, nil)
 			mockAPIHelper.On("GetNode", mockClient).Return(nil, expectedError).Once()
-			err := advertiseFeatureLabels(testHelper, fakeFeatureLabels, fakeAnnotations)
+			err := advertiseFeatureLabels(testHelper, fakeFeatureLabels, fakeAnnotations, false)
 
 			Convey("Error is produced", func() {
 				So(err, ShouldEqual, expectedError)
@@ -128,7 +223,7 @@ func TestDiscoveryWithMockSources(t *testing.T) {
 			mockAPIHelper.On("AddLabels", mockNode, fakeFeatureLabels).Return().Once()
 			mockAPIHelper.On("AddAnnotations", mockNode, fakeAnnotations).Return().Once()
 			mockAPIHelper.On("UpdateNode", mockClient, mockNode).Return(expectedError).Once()
-			err := advertiseFeatureLabels(testHelper, fakeFeatureLabels, fakeAnnotations)
+			err := advertiseFeatureLabels(testHelper, fakeFeatureLabels, fakeAnnotations, false)
 
 			Convey("Error is produced", func() {
 				So(err, ShouldEqual, expectedError)
@@ -150,9 +245,19 @@ func TestArgsParse(t *testing.T) {
 
 			Convey("noPublish is set and args.sources is set to the default value", func() {
 				So(args.sleepInterval, ShouldEqual, 60*time.Second)
+				So(args.updateDelay, ShouldEqual, 0)
+				So(args.noNetwork, ShouldBeFalse)
+				So(args.priorityLabels, ShouldEqual, "")
+				So(args.exportFile, ShouldEqual, "")
+				So(args.bootstrapFile, ShouldEqual, "")
+				So(args.legacyLabels, ShouldBeFalse)
+				So(args.maxLabels, ShouldEqual, 0)
+				So(args.labelPriority, ShouldEqual, "*,^cpuid-")
+				So(args.fingerprintSources, ShouldResemble, []string{"cpu", "cpuid", "memory", "pci"})
+				So(args.unknownSources, ShouldEqual, "warn")
 				So(args.noPublish, ShouldBeTrue)
 				So(args.oneshot, ShouldBeTrue)
-				So(args.sources, ShouldResemble, []string{"cpu", "cpuid", "iommu", "kernel", "local", "memory", "network", "pci", "pstate", "rdt", "storage", "system"})
+				So(args.sources, ShouldResemble, []string{"accelerator", "cpu", "cpuid", "iommu", "kernel", "local", "memory", "network", "pci", "pstate", "rdma", "rdt", "security", "storage", "system", "virtualization"})
 				So(len(args.labelWhiteList), ShouldEqual, 0)
 			})
 		})
@@ -174,7 +279,7 @@ func TestArgsParse(t *testing.T) {
 
 			Convey("args.labelWhiteList is set to appropriate value and args.sources is set to default value", func() {
 				So(args.noPublish, ShouldBeFalse)
-				So(args.sources, ShouldResemble, []string{"cpu", "cpuid", "iommu", "kernel", "local", "memory", "network", "pci", "pstate", "rdt", "storage", "system"})
+				So(args.sources, ShouldResemble, []string{"accelerator", "cpu", "cpuid", "iommu", "kernel", "local", "memory", "network", "pci", "pstate", "rdma", "rdt", "security", "storage", "system", "virtualization"})
 				So(args.labelWhiteList, ShouldResemble, ".*rdt.*")
 			})
 		})
@@ -205,6 +310,14 @@ func TestConfigParse(t *testing.T) {
 		defer os.Remove(f.Name())
 		So(err, ShouldBeNil)
 		f.WriteString(`sources:
+  accelerator:
+    devices:
+      - vendor: "1d0f"
+        device: "efa0"
+        label: "aws-efa.present"
+      - class: "0300"
+        label: "gpu.kernel"
+        value: "{{ .pci.driver }}-{{ .kernel.version.major }}"
   kernel:
     configOpts:
       - "DMI"
@@ -218,6 +331,7 @@ func TestConfigParse(t *testing.T) {
 
 			Convey("Should return error", func() {
 				So(err, ShouldBeNil)
+				So(config.Sources.Accelerator.Devices, ShouldResemble, []accelerator.DeviceRule{{Vendor: "1d0f", Device: "efa0", Label: "aws-efa.present"}, {Class: "0300", Label: "gpu.kernel", Value: "{{ .pci.driver }}-{{ .kernel.version.major }}"}})
 				So(config.Sources.Kernel.ConfigOpts, ShouldResemble, []string{"DMI"})
 				So(config.Sources.Pci.DeviceClassWhitelist, ShouldResemble, []string{"ff"})
 			})
@@ -225,6 +339,72 @@ func TestConfigParse(t *testing.T) {
 	})
 }
 
+func TestNodeOverrides(t *testing.T) {
+	// The overrides modify the global configuration of the sources. Restore
+	// it afterwards, copying the slices as they are decoded into in place.
+	kernelConfig, pciConfig := kernel.Config, pci.Config
+	kernelConfig.ConfigOpts = append([]string{}, kernel.Config.ConfigOpts...)
+	kernelConfig.CmdlineParams = append([]string{}, kernel.Config.CmdlineParams...)
+	pciConfig.DeviceClassWhitelist = append([]string{}, pci.Config.DeviceClassWhitelist...)
+	pciConfig.DeviceLabelFields = append([]string{}, pci.Config.DeviceLabelFields...)
+	defer func() {
+		kernel.Config, pci.Config = kernelConfig, pciConfig
+		config.NodeOverrides = nil
+	}()
+
+	Convey("When applying node overrides", t, func() {
+		err := configParse("non-existing-file", "")
+		So(err, ShouldNotBeNil)
+		err = yaml.Unmarshal([]byte(`
+sources:
+  kernel:
+    configOpts: ["DMI"]
+nodeOverrides:
+  - nodeName: "rt-.*"
+    extraSources: ["fake"]
+    config:
+      sources:
+        kernel:
+          configOpts: ["PREEMPT_RT"]
+  - nodeLabels:
+      node-role.kubernetes.io/gpu: ""
+    config:
+      sources:
+        pci:
+          deviceClassWhitelist: ["03"]`), &config)
+		So(err, ShouldBeNil)
+
+		Convey("When no override matches the node", func() {
+			extraSources, err := applyNodeOverrides("worker-1", map[string]string{})
+
+			Convey("Configuration should not be changed", func() {
+				So(err, ShouldBeNil)
+				So(extraSources, ShouldBeEmpty)
+				So(config.Sources.Kernel.ConfigOpts, ShouldResemble, []string{"DMI"})
+			})
+		})
+		Convey("When overrides match the node name and labels", func() {
+			extraSources, err := applyNodeOverrides("rt-1", map[string]string{"node-role.kubernetes.io/gpu": ""})
+
+			Convey("Configuration of all matching overrides should be applied", func() {
+				So(err, ShouldBeNil)
+				So(extraSources, ShouldResemble, []string{"fake"})
+				So(config.Sources.Kernel.ConfigOpts, ShouldResemble, []string{"PREEMPT_RT"})
+				So(config.Sources.Pci.DeviceClassWhitelist, ShouldResemble, []string{"03"})
+				So(len(config.NodeOverrides), ShouldEqual, 2)
+			})
+		})
+		Convey("When the node name pattern is invalid", func() {
+			config.NodeOverrides = []NodeOverride{{NodeName: "("}}
+			_, err := applyNodeOverrides("rt-1", nil)
+
+			Convey("Error should be returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
 func TestConfigureParameters(t *testing.T) {
 	Convey("When configuring parameters for node feature discovery", t, func() {
 
@@ -232,7 +412,7 @@ func TestConfigureParameters(t *testing.T) {
 			sourcesWhiteList := []string{}
 			labelWhiteListStr := ""
 			emptyRegexp, _ := regexp.Compile("")
-			enabledSources, labelWhiteList, err := configureParameters(sourcesWhiteList, labelWhiteListStr)
+			enabledSources, labelWhiteList, err := configureParameters(sourcesWhiteList, labelWhiteListStr, "warn")
 
 			Convey("Error should not be produced", func() {
 				So(err, ShouldBeNil)
@@ -247,7 +427,7 @@ func TestConfigureParameters(t *testing.T) {
 			sourcesWhiteList := []string{"fake"}
 			labelWhiteListStr := ""
 			emptyRegexp, _ := regexp.Compile("")
-			enabledSources, labelWhiteList, err := configureParameters(sourcesWhiteList, labelWhiteListStr)
+			enabledSources, labelWhiteList, err := configureParameters(sourcesWhiteList, labelWhiteListStr, "warn")
 
 			Convey("Error should not be produced", func() {
 				So(err, ShouldBeNil)
@@ -259,10 +439,30 @@ func TestConfigureParameters(t *testing.T) {
 			})
 		})
 
+		Convey("When unknown sources are passed", func() {
+			sourcesWhiteList := []string{"fake", "cpuID"}
+
+			Convey("Unknown sources are ignored if configured so", func() {
+				enabledSources, _, err := configureParameters(sourcesWhiteList, "", "ignore")
+				So(err, ShouldBeNil)
+				So(len(enabledSources), ShouldEqual, 1)
+			})
+			Convey("Unknown sources are warned about if configured so", func() {
+				enabledSources, _, err := configureParameters(sourcesWhiteList, "", "warn")
+				So(err, ShouldBeNil)
+				So(len(enabledSources), ShouldEqual, 1)
+			})
+			Convey("Error is produced if configured so", func() {
+				enabledSources, _, err := configureParameters(sourcesWhiteList, "", "error")
+				So(enabledSources, ShouldBeNil)
+				So(err, ShouldNotBeNil)
+			})
+		})
+
 		Convey("When invalid labelWhiteListStr is passed", func() {
 			sourcesWhiteList := []string{""}
 			labelWhiteListStr := "*"
-			enabledSources, labelWhiteList, err := configureParameters(sourcesWhiteList, labelWhiteListStr)
+			enabledSources, labelWhiteList, err := configureParameters(sourcesWhiteList, labelWhiteListStr, "warn")
 
 			Convey("Error is produced", func() {
 				So(enabledSources, ShouldBeNil)
@@ -275,7 +475,7 @@ func TestConfigureParameters(t *testing.T) {
 			sourcesWhiteList := []string{""}
 			labelWhiteListStr := ".*rdt.*"
 			expectRegexp, err := regexp.Compile(".*rdt.*")
-			enabledSources, labelWhiteList, err := configureParameters(sourcesWhiteList, labelWhiteListStr)
+			enabledSources, labelWhiteList, err := configureParameters(sourcesWhiteList, labelWhiteListStr, "warn")
 
 			Convey("Error should not be produced", func() {
 				So(err, ShouldBeNil)
@@ -288,6 +488,83 @@ func TestConfigureParameters(t *testing.T) {
 	})
 }
 
+// networkSource is a fake feature source that accesses the network
+type networkSource struct {
+	fake.Source
+}
+
+func (s networkSource) UsesNetwork() bool { return true }
+
+func TestDisableNetworkSources(t *testing.T) {
+	Convey("When disabling sources that access the network", t, func() {
+		sources := []source.FeatureSource{fake.Source{}, networkSource{}}
+		enabledSources := disableNetworkSources(sources)
+
+		Convey("Only local sources are returned", func() {
+			So(len(enabledSources), ShouldEqual, 1)
+			So(enabledSources[0], ShouldHaveSameTypeAs, fake.Source{})
+		})
+	})
+}
+
+// driverSource is a fake feature source that reports drivers
+type driverSource struct {
+	fake.Source
+}
+
+func (s driverSource) Drivers() ([]source.Driver, error) {
+	return []source.Driver{{Device: "0000:00:01.0", Driver: "fakedriver", Version: "1.0"}}, nil
+}
+
+func TestCreateDriverManifest(t *testing.T) {
+	Convey("When creating the driver manifest", t, func() {
+		Convey("When none of the sources reports drivers", func() {
+			drivers := createDriverManifest([]source.FeatureSource{fake.Source{}})
+
+			Convey("No manifest is created", func() {
+				So(drivers, ShouldBeNil)
+			})
+		})
+
+		Convey("When a source reports drivers", func() {
+			drivers := createDriverManifest([]source.FeatureSource{fake.Source{}, driverSource{}})
+
+			Convey("The drivers are included in the manifest", func() {
+				So(drivers, ShouldResemble, []source.Driver{{Device: "0000:00:01.0", Driver: "fakedriver", Version: "1.0"}})
+			})
+		})
+	})
+}
+
+// orderedSource is a fake feature source with a priority
+type orderedSource struct {
+	name     string
+	priority int
+}
+
+func (s orderedSource) Name() string                       { return s.name }
+func (s orderedSource) Discover() (source.Features, error) { return source.Features{}, nil }
+func (s orderedSource) Priority() int                      { return s.priority }
+
+func TestOrderSources(t *testing.T) {
+	Convey("When ordering feature sources", t, func() {
+		ordered := orderSources([]source.FeatureSource{
+			orderedSource{name: "a", priority: 10},
+			orderedSource{name: "b"},
+			orderedSource{name: "c", priority: -1},
+			orderedSource{name: "d"},
+		})
+
+		Convey("Sources are ordered by priority, keeping the order of equal priorities", func() {
+			names := []string{}
+			for _, s := range ordered {
+				names = append(names, s.Name())
+			}
+			So(names, ShouldResemble, []string{"c", "b", "d", "a"})
+		})
+	})
+}
+
 func TestCreateFeatureLabels(t *testing.T) {
 	Convey("When creating feature labels from the configured sources", t, func() {
 		Convey("When fake feature source is configured", func() {
@@ -295,7 +572,7 @@ func TestCreateFeatureLabels(t *testing.T) {
 			fakeFeatureSource := source.FeatureSource(new(fake.Source))
 			sources := []source.FeatureSource{}
 			sources = append(sources, fakeFeatureSource)
-			labels := createFeatureLabels(sources, emptyLabelWL)
+			labels := createFeatureLabels(sources, emptyLabelWL, nil)
 
 			Convey("Proper fake labels are returned", func() {
 				So(len(labels), ShouldEqual, 3)
@@ -304,12 +581,45 @@ func TestCreateFeatureLabels(t *testing.T) {
 				So(labels, ShouldContainKey, "fake-fakefeature3")
 			})
 		})
+		Convey("When fake feature source is configured with source timestamps", func() {
+			emptyLabelWL, _ := regexp.Compile("")
+			timestamps := SourceTimestamps{}
+			sources := []source.FeatureSource{source.FeatureSource(new(fake.Source)), source.FeatureSource(new(panic_fake.Source))}
+			createFeatureLabels(sources, emptyLabelWL, timestamps)
+
+			Convey("Only successful sources are timestamped", func() {
+				So(timestamps, ShouldContainKey, "fake")
+				So(timestamps, ShouldNotContainKey, "panic_fake")
+			})
+		})
+		Convey("When fake feature source is configured with custom features", func() {
+			emptyLabelWL, _ := regexp.Compile("")
+			fake.Config.Features = map[string]string{"custom": "value"}
+			defer func() { fake.Config.Features = nil }()
+			sources := []source.FeatureSource{source.FeatureSource(new(fake.Source))}
+			labels := createFeatureLabels(sources, emptyLabelWL, nil)
+
+			Convey("Only the configured labels are returned", func() {
+				So(labels, ShouldResemble, Labels{"fake-custom": "value"})
+			})
+		})
+		Convey("When fake feature source is configured to always fail", func() {
+			emptyLabelWL, _ := regexp.Compile("")
+			fake.Config.ErrorRate = 1.0
+			defer func() { fake.Config.ErrorRate = 0 }()
+			sources := []source.FeatureSource{source.FeatureSource(new(fake.Source))}
+			labels := createFeatureLabels(sources, emptyLabelWL, nil)
+
+			Convey("No labels are returned", func() {
+				So(len(labels), ShouldEqual, 0)
+			})
+		})
 		Convey("When fake feature source is configured with a whitelist that doesn't match", func() {
 			emptyLabelWL, _ := regexp.Compile(".*rdt.*")
 			fakeFeatureSource := source.FeatureSource(new(fake.Source))
 			sources := []source.FeatureSource{}
 			sources = append(sources, fakeFeatureSource)
-			labels := createFeatureLabels(sources, emptyLabelWL)
+			labels := createFeatureLabels(sources, emptyLabelWL, nil)
 
 			Convey("fake labels are not returned", func() {
 				So(len(labels), ShouldEqual, 0)
@@ -348,6 +658,26 @@ func TestAddLabels(t *testing.T) {
 	})
 }
 
+func TestAddLegacyLabels(t *testing.T) {
+	Convey("When adding labels in the legacy format", t, func() {
+		helper := k8sHelpers{}
+		n := &api.Node{}
+		longName := "cpu-" + strings.Repeat("x", 56)
+		helper.AddLegacyLabels(n, Labels{"cpuid-AVX": "true", longName: "true"})
+
+		Convey("Feature labels are added with the legacy prefix", func() {
+			So(n.Labels, ShouldContainKey, "node.alpha.kubernetes-incubator.io/nfd-cpuid-AVX")
+		})
+		Convey("Labels exceeding the name length limit with the legacy prefix are skipped", func() {
+			So(n.Labels, ShouldNotContainKey, "node.alpha.kubernetes-incubator.io/nfd-"+longName)
+			So(len(n.Labels), ShouldEqual, 2)
+		})
+		Convey("Legacy version label is added", func() {
+			So(n.Labels["node.alpha.kubernetes-incubator.io/node-feature-discovery.version"], ShouldEqual, version)
+		})
+	})
+}
+
 func TestRemoveLabelsWithPrefix(t *testing.T) {
 	Convey("When removing labels", t, func() {
 		helper := k8sHelpers{}
@@ -384,6 +714,323 @@ func TestRemoveLabelsWithPrefix(t *testing.T) {
 	})
 }
 
+func TestLabelNames(t *testing.T) {
+	Convey("When getting the names of labels", t, func() {
+		labels := Labels{"b": "1", "c": "2", "a": "3"}
+
+		Convey("Names are returned in sorted order", func() {
+			So(labelNames(labels), ShouldResemble, []string{"a", "b", "c"})
+		})
+	})
+}
+
+func TestLabelQuota(t *testing.T) {
+	labels := Labels{
+		"cpuid-AVX":            "true",
+		"cpuid-SSE":            "true",
+		"kernel-version.os":    "4.19",
+		"pci-0300.present":     "true",
+		"security-tpm.present": "true",
+	}
+
+	Convey("When the number of labels is within the quota", t, func() {
+		q, err := newLabelQuota(5, "*,^cpuid-")
+		So(err, ShouldBeNil)
+		kept, dropped := q.apply(labels)
+
+		Convey("All labels are kept", func() {
+			So(kept, ShouldResemble, labels)
+			So(dropped, ShouldBeNil)
+		})
+	})
+
+	Convey("When the quota is unlimited", t, func() {
+		q, err := newLabelQuota(0, "*,^cpuid-")
+		So(err, ShouldBeNil)
+		kept, dropped := q.apply(labels)
+
+		Convey("All labels are kept", func() {
+			So(kept, ShouldResemble, labels)
+			So(dropped, ShouldBeNil)
+		})
+	})
+
+	Convey("When the number of labels exceeds the quota", t, func() {
+		q, err := newLabelQuota(3, "^security-,*,^cpuid-")
+		So(err, ShouldBeNil)
+		kept, dropped := q.apply(labels)
+
+		Convey("Lowest priority labels are dropped", func() {
+			So(kept, ShouldResemble, Labels{
+				"kernel-version.os":    "4.19",
+				"pci-0300.present":     "true",
+				"security-tpm.present": "true"})
+			So(dropped, ShouldResemble, []string{"cpuid-AVX", "cpuid-SSE"})
+		})
+	})
+
+	Convey("When unmatched labels are not given a priority", t, func() {
+		q, err := newLabelQuota(2, "^cpuid-")
+		So(err, ShouldBeNil)
+		kept, dropped := q.apply(labels)
+
+		Convey("Unmatched labels have the lowest priority", func() {
+			So(kept, ShouldResemble, Labels{"cpuid-AVX": "true", "cpuid-SSE": "true"})
+			So(dropped, ShouldResemble, []string{"kernel-version.os", "pci-0300.present", "security-tpm.present"})
+		})
+	})
+
+	Convey("When an invalid priority pattern is given", t, func() {
+		_, err := newLabelQuota(2, "^cpuid-,[")
+
+		Convey("An error is returned", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestHardwareFingerprint(t *testing.T) {
+	labels := Labels{
+		"cpu-cores":          "8",
+		"cpu-model":          "85",
+		"kernel-version.os":  "4.19",
+		"pci-0300.present":   "true",
+		"pci-0300.sriov":     "true",
+		"security-uefi.boot": "true",
+	}
+	sources := []string{"cpu", "pci"}
+
+	Convey("When labels of other sources change", t, func() {
+		changed := Labels{}
+		for k, v := range labels {
+			changed[k] = v
+		}
+		changed["kernel-version.os"] = "5.4"
+		delete(changed, "security-uefi.boot")
+
+		Convey("Fingerprint is unchanged", func() {
+			So(hardwareFingerprint(changed, sources), ShouldEqual, hardwareFingerprint(labels, sources))
+		})
+	})
+
+	Convey("When labels of the fingerprinted sources change", t, func() {
+		changed := Labels{}
+		for k, v := range labels {
+			changed[k] = v
+		}
+		changed["cpu-model"] = "106"
+
+		Convey("Fingerprint changes", func() {
+			So(hardwareFingerprint(changed, sources), ShouldNotEqual, hardwareFingerprint(labels, sources))
+			So(hardwareFingerprint(labels, sources), ShouldStartWith, "sha256:")
+		})
+	})
+
+	Convey("When non-hardware labels of the fingerprinted sources change", t, func() {
+		changed := Labels{}
+		for k, v := range labels {
+			changed[k] = v
+		}
+		changed["cpu-cores"] = "6"
+		changed["pci-0300.driver_ready"] = "true"
+
+		Convey("Fingerprint is unchanged", func() {
+			So(hardwareFingerprint(changed, sources), ShouldEqual, hardwareFingerprint(labels, sources))
+		})
+	})
+
+	Convey("When no sources are given", t, func() {
+		Convey("Fingerprint is empty", func() {
+			So(hardwareFingerprint(labels, nil), ShouldEqual, "")
+		})
+	})
+}
+
+func TestAsyncPublisher(t *testing.T) {
+	Convey("When publishing node updates asynchronously", t, func() {
+		block := make(chan struct{})
+		published := []Labels{}
+		marked := []Labels{}
+		var publishErr error
+		p := newAsyncPublisher(2, func(u nodeUpdate) error {
+			<-block
+			published = append(published, u.labels)
+			return publishErr
+		}, func(u nodeUpdate) {
+			marked = append(marked, u.labels)
+		})
+
+		Convey("When updates are queued faster than they are published", func() {
+			// The first update is picked up by the publisher, the
+			// others wait in the queue
+			p.enqueue(nodeUpdate{labels: Labels{"update": "1"}})
+			for len(p.queue) > 0 {
+				time.Sleep(time.Millisecond)
+			}
+			p.enqueue(nodeUpdate{labels: Labels{"update": "2"}})
+			p.enqueue(nodeUpdate{labels: Labels{"update": "3"}})
+			p.enqueue(nodeUpdate{labels: Labels{"update": "4"}})
+			So(p.pending.Value(), ShouldEqual, 2)
+			close(block)
+			err := p.stop()
+
+			Convey("Oldest pending updates are dropped", func() {
+				So(err, ShouldBeNil)
+				So(p.dropped.Value(), ShouldEqual, 1)
+				So(published, ShouldResemble, []Labels{{"update": "1"}, {"update": "3"}, {"update": "4"}})
+				So(marked, ShouldResemble, published)
+			})
+		})
+
+		Convey("When publishing fails", func() {
+			publishErr = errors.New("fake error")
+			p.enqueue(nodeUpdate{labels: Labels{"update": "1"}})
+			close(block)
+			err := p.stop()
+
+			Convey("The error is returned when stopping", func() {
+				So(err, ShouldEqual, publishErr)
+			})
+			Convey("The update is not marked as published", func() {
+				So(published, ShouldResemble, []Labels{{"update": "1"}})
+				So(marked, ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestBootstrap(t *testing.T) {
+	Convey("When bootstrapping the node labels", t, func() {
+		labels := Labels{"cpu-model": "85", "cpuid-AVX": "true"}
+
+		Convey("Labels should be formatted for kubelet", func() {
+			So(kubeletNodeLabels(labels), ShouldEqual, labelNs+"cpu-model=85,"+labelNs+"cpuid-AVX=true")
+		})
+
+		mockAPIHelper := new(MockAPIHelpers)
+		mockNode := &api.Node{}
+		var mockClient *k8sclient.Clientset
+
+		Convey("When the node gets registered", func() {
+			mockAPIHelper.On("GetClient").Return(mockClient, nil)
+			mockAPIHelper.On("GetNode", mockClient).Return(nil, errors.New("not found")).Once()
+			mockAPIHelper.On("GetNode", mockClient).Return(mockNode, nil).Once()
+			mockAPIHelper.On("AddAnnotations", mockNode, Annotations{"feature-labels": "cpu-model,cpuid-AVX"}).Return().Once()
+			mockAPIHelper.On("UpdateNode", mockClient, mockNode).Return(nil).Once()
+			err := waitForNodeRegistration(mockAPIHelper, labels, time.Millisecond, time.Second)
+
+			Convey("Bootstrap labels should be recorded as labels managed by NFD", func() {
+				So(err, ShouldBeNil)
+				mockAPIHelper.AssertExpectations(t)
+			})
+		})
+
+		Convey("When the API server can't be accessed", func() {
+			mockAPIHelper.On("GetClient").Return(mockClient, errors.New("no credentials"))
+			err := waitForNodeRegistration(mockAPIHelper, labels, time.Millisecond, 10*time.Millisecond)
+
+			Convey("Waiting should time out with the last error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "no credentials")
+			})
+		})
+	})
+}
+
+func TestUpdateThrottle(t *testing.T) {
+	Convey("When throttling node updates", t, func() {
+		now := time.Now()
+		labels := Labels{"fake-feature": "true", "fake-gpu.healthy": "true"}
+
+		Convey("When no update delay is configured", func() {
+			throttle, err := newUpdateThrottle(0, "")
+			So(err, ShouldBeNil)
+			throttle.updated(labels, now)
+
+			Convey("Every pass should update the node", func() {
+				So(throttle.shouldUpdate(labels, now), ShouldBeTrue)
+			})
+		})
+
+		Convey("When an update delay is configured", func() {
+			throttle, err := newUpdateThrottle(time.Minute, `.*\.healthy$`)
+			So(err, ShouldBeNil)
+
+			Convey("The first pass should update the node", func() {
+				So(throttle.shouldUpdate(labels, now), ShouldBeTrue)
+			})
+
+			throttle.updated(labels, now)
+
+			Convey("Routine changes should be coalesced until the delay has passed", func() {
+				changed := Labels{"fake-feature": "false", "fake-gpu.healthy": "true"}
+				So(throttle.shouldUpdate(changed, now.Add(time.Second)), ShouldBeFalse)
+				So(throttle.shouldUpdate(changed, now.Add(time.Minute)), ShouldBeTrue)
+			})
+			Convey("Changed high-priority labels should bypass the delay", func() {
+				changed := Labels{"fake-feature": "true", "fake-gpu.healthy": "false"}
+				So(throttle.shouldUpdate(changed, now.Add(time.Second)), ShouldBeTrue)
+			})
+			Convey("Removed high-priority labels should bypass the delay", func() {
+				changed := Labels{"fake-feature": "true"}
+				So(throttle.shouldUpdate(changed, now.Add(time.Second)), ShouldBeTrue)
+			})
+			Convey("Resetting should bypass the delay", func() {
+				throttle.reset()
+				So(throttle.shouldUpdate(labels, now.Add(time.Second)), ShouldBeTrue)
+			})
+		})
+
+		Convey("When an invalid priority pattern is given", func() {
+			_, err := newUpdateThrottle(time.Minute, "*")
+
+			Convey("Error is produced", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestRedfishSystem(t *testing.T) {
+	Convey("When converting feature labels to a Redfish ComputerSystem", t, func() {
+		labels := Labels{
+			"cpuid-AVX":                "true",
+			"security-tpm.version":     "2.0",
+			"security-uefi.enabled":    "true",
+			"security-uefi.secureboot": "true",
+			"my-hook-feature":          "true",
+			"hookfeature":              "true",
+		}
+		system := redfishSystem("node-1", labels, []string{"cpuid", "security", "local"})
+
+		Convey("Node name is used as the system identity", func() {
+			So(system.Id, ShouldEqual, "node-1")
+			So(system.Name, ShouldEqual, "node-1")
+		})
+		Convey("Standard properties are filled in", func() {
+			So(system.TrustedModules, ShouldResemble, []RedfishTrustedModule{{InterfaceType: "TPM2_0"}})
+			So(system.SecureBoot, ShouldResemble, &RedfishSecureBoot{SecureBootEnable: true})
+		})
+		Convey("All features are grouped by source", func() {
+			features := system.Oem.NodeFeatureDiscovery.Features
+			So(features["cpuid"], ShouldResemble, map[string]string{"AVX": "true"})
+			So(features["security"], ShouldResemble, map[string]string{
+				"tpm.version": "2.0", "uefi.enabled": "true", "uefi.secureboot": "true"})
+			So(features["local"], ShouldResemble, map[string]string{"my-hook-feature": "true", "hookfeature": "true"})
+			So(features, ShouldNotContainKey, "my")
+		})
+	})
+
+	Convey("When no TPM or UEFI features are present", t, func() {
+		system := redfishSystem("node-1", Labels{}, nil)
+
+		Convey("The corresponding properties are omitted", func() {
+			So(system.TrustedModules, ShouldBeNil)
+			So(system.SecureBoot, ShouldBeNil)
+		})
+	})
+}
+
 func TestGetFeatureLabels(t *testing.T) {
 	Convey("When I get feature labels and panic occurs during discovery of a feature source", t, func() {
 		fakePanicFeatureSource := source.FeatureSource(new(panic_fake.Source))
@@ -76,6 +76,12 @@ func (_m *MockAPIHelpers) AddLabels(_a0 *api.Node, _a1 Labels) {
 	_m.Called(_a0, _a1)
 }
 
+// AddLegacyLabels provides a mock function with *api.Node and main.Labels as the input arguments and
+// no return value
+func (_m *MockAPIHelpers) AddLegacyLabels(_a0 *api.Node, _a1 Labels) {
+	_m.Called(_a0, _a1)
+}
+
 // AddAnnotations provides a mock function with *api.Node and main.Annotations as the input arguments and
 // no return value
 func (_m *MockAPIHelpers) AddAnnotations(_a0 *api.Node, _a1 Annotations) {
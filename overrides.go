@@ -0,0 +1,147 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/ghodss/yaml"
+)
+
+// NodeOverride is a configuration overlay applied only on matching nodes
+type NodeOverride struct {
+	// NodeName is a regular expression that the node name must match
+	NodeName string `json:"nodeName,omitempty"`
+	// NodeLabels are labels that the node must have
+	NodeLabels map[string]string `json:"nodeLabels,omitempty"`
+	// ExtraSources are feature sources enabled in addition to --sources
+	ExtraSources []string `json:"extraSources,omitempty"`
+	// Config is merged on top of the global configuration
+	Config json.RawMessage `json:"config,omitempty"`
+}
+
+// matches checks if the override applies to a node
+func (o NodeOverride) matches(nodeName string, nodeLabels map[string]string) (bool, error) {
+	if o.NodeName != "" {
+		re, err := regexp.Compile("^(?:" + o.NodeName + ")$")
+		if err != nil {
+			return false, fmt.Errorf("invalid nodeName %q: %s", o.NodeName, err)
+		}
+		if !re.MatchString(nodeName) {
+			return false, nil
+		}
+	}
+	for k, v := range o.NodeLabels {
+		if l, ok := nodeLabels[k]; !ok || l != v {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// applyNodeOverrides merges the configuration of all overrides matching the
+// node, in the order they are specified, and returns the extra feature
+// sources enabled by them.
+func applyNodeOverrides(nodeName string, nodeLabels map[string]string) ([]string, error) {
+	extraSources := []string{}
+
+	overrides := config.NodeOverrides
+	for i, o := range overrides {
+		match, err := o.matches(nodeName, nodeLabels)
+		if err != nil {
+			return nil, fmt.Errorf("node override #%d: %s", i, err)
+		}
+		if !match {
+			continue
+		}
+		stdoutLogger.Printf("applying node override #%d", i)
+		if len(o.Config) > 0 {
+			if err := json.Unmarshal(o.Config, &config); err != nil {
+				return nil, fmt.Errorf("node override #%d: failed to parse config: %s", i, err)
+			}
+		}
+		extraSources = append(extraSources, o.ExtraSources...)
+	}
+	// Overrides may not redefine the set of overrides
+	config.NodeOverrides = overrides
+
+	return extraSources, nil
+}
+
+// configureNodeOverrides applies the node overrides of the configuration to
+// this node. Labels of the node are only fetched from the API server if any
+// of the overrides match on node labels. Config options given on the command
+// line take precedence over the overrides.
+func configureNodeOverrides(helper APIHelpers, options string) ([]string, error) {
+	if len(config.NodeOverrides) == 0 {
+		return nil, nil
+	}
+
+	var nodeLabels map[string]string
+	for _, o := range config.NodeOverrides {
+		if len(o.NodeLabels) > 0 {
+			labels, err := getNodeLabels(helper)
+			if err != nil {
+				stderrLogger.Printf("can't get node labels, overrides matching on node labels are not applied: %s", err.Error())
+			}
+			nodeLabels = labels
+			break
+		}
+	}
+
+	extraSources, err := applyNodeOverrides(os.Getenv(NodeNameEnv), nodeLabels)
+	if err != nil {
+		return nil, err
+	}
+
+	err = yaml.Unmarshal([]byte(options), &config)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse --options: %s", err)
+	}
+
+	return extraSources, nil
+}
+
+// getContainerRuntimeVersion returns the container runtime version of this
+// node, as reported by the kubelet, e.g. containerd://1.6.8
+func getContainerRuntimeVersion(helper APIHelpers) (string, error) {
+	cli, err := helper.GetClient()
+	if err != nil {
+		return "", err
+	}
+	node, err := helper.GetNode(cli)
+	if err != nil {
+		return "", err
+	}
+	return node.Status.NodeInfo.ContainerRuntimeVersion, nil
+}
+
+// getNodeLabels returns the current labels of this node
+func getNodeLabels(helper APIHelpers) (map[string]string, error) {
+	cli, err := helper.GetClient()
+	if err != nil {
+		return nil, err
+	}
+	node, err := helper.GetNode(cli)
+	if err != nil {
+		return nil, err
+	}
+	return node.Labels, nil
+}
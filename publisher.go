@@ -0,0 +1,120 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"expvar"
+	"time"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+// nodeUpdate holds the results of one discovery pass to be published.
+type nodeUpdate struct {
+	labels      Labels
+	timestamps  SourceTimestamps
+	drivers     []source.Driver
+	dropped     []string
+	bootID      string
+	fingerprint string
+}
+
+// Publisher metrics, exported with expvar: the number of updates pending in
+// the queue, and the total numbers of published, failed and dropped updates
+var publisherMetrics = expvar.NewMap("publisher")
+
+// asyncPublisher publishes node updates in the background, so that slow API
+// server writes do not delay discovery. Pending updates are held in a bounded
+// queue. If the queue is full, the oldest pending update is dropped as it
+// would be superseded by the newer ones anyway.
+type asyncPublisher struct {
+	queue   chan nodeUpdate
+	publish func(nodeUpdate) error
+	// Called after an update has been published successfully
+	published func(nodeUpdate)
+	done      chan struct{}
+	// Error of the last publish attempt, read after done is closed
+	err error
+	// Number of updates dropped because of a full queue
+	dropped expvar.Int
+	pending expvar.Int
+}
+
+// newAsyncPublisher creates a new asyncPublisher with the given queue size
+// and starts publishing in the background.
+func newAsyncPublisher(size int, publish func(nodeUpdate) error, published func(nodeUpdate)) *asyncPublisher {
+	p := &asyncPublisher{
+		queue:     make(chan nodeUpdate, size),
+		publish:   publish,
+		published: published,
+		done:      make(chan struct{}),
+	}
+	publisherMetrics.Set("dropped", &p.dropped)
+	publisherMetrics.Set("pending", &p.pending)
+	go p.run()
+	return p
+}
+
+func (p *asyncPublisher) run() {
+	defer close(p.done)
+	for u := range p.queue {
+		p.pending.Set(int64(len(p.queue)))
+		start := time.Now()
+		p.err = p.publish(u)
+		if err := p.err; err != nil {
+			// The update is not marked as published, so that it is
+			// retried on the next discovery pass
+			publisherMetrics.Add("failed", 1)
+			stderrLogger.Printf("ERROR: failed to update node with feature labels: %s", err.Error())
+			continue
+		}
+		publisherMetrics.Add("published", 1)
+		if p.published != nil {
+			p.published(u)
+		}
+		stdoutLogger.Printf("node update published in %s, %d update(s) pending, %d dropped in total",
+			time.Since(start), len(p.queue), p.dropped.Value())
+	}
+}
+
+// enqueue queues an update for publishing without blocking. Must not be
+// called concurrently.
+func (p *asyncPublisher) enqueue(u nodeUpdate) {
+	for {
+		select {
+		case p.queue <- u:
+			p.pending.Set(int64(len(p.queue)))
+			return
+		default:
+		}
+		// Queue is full, make room by dropping the oldest update
+		select {
+		case <-p.queue:
+			p.dropped.Add(1)
+			stderrLogger.Printf("WARNING: node update queue full, dropping oldest pending update (%d dropped in total)", p.dropped.Value())
+		default:
+		}
+	}
+}
+
+// stop waits until all pending updates have been published, and returns the
+// error of the last update, if it failed.
+func (p *asyncPublisher) stop() error {
+	close(p.queue)
+	<-p.done
+	return p.err
+}
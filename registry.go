@@ -0,0 +1,43 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"sort"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+// sourcePriority returns the priority of a feature source
+func sourcePriority(s source.FeatureSource) int {
+	if p, ok := s.(source.PrioritySource); ok {
+		return p.Priority()
+	}
+	return 0
+}
+
+// orderSources returns the feature sources ordered by priority, keeping the
+// original order of sources with equal priority. Labels from sources ordered
+// later override the labels of sources ordered earlier.
+func orderSources(sources []source.FeatureSource) []source.FeatureSource {
+	ordered := make([]source.FeatureSource, len(sources))
+	copy(ordered, sources)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return sourcePriority(ordered[i]) < sourcePriority(ordered[j])
+	})
+	return ordered
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accelerator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+const sysfsPciDevices = "/sys/bus/pci/devices"
+
+var logger = log.New(os.Stderr, "", log.LstdFlags)
+
+// Configuration file options
+type NFDConfig struct {
+	// PCI devices to detect in addition to the built-in ones
+	Devices []DeviceRule `json:"devices,omitempty"`
+}
+
+// DeviceRule publishes a feature if PCI devices matching the rule are present
+type DeviceRule struct {
+	// PCI vendor and device IDs, and a prefix of the device class, e.g.
+	// "1d0f", "efa0" and "0200". Empty fields match any device.
+	Vendor string `json:"vendor,omitempty"`
+	Device string `json:"device,omitempty"`
+	Class  string `json:"class,omitempty"`
+	// Name of the feature to publish, e.g. "aws-efa.present"
+	Label string `json:"label"`
+	// Value of the feature, "true" if empty. May be a template referring
+	// to attributes of the first matching device, and of the kernel, e.g.
+	// "{{ .pci.device }}" or "{{ .kernel.version.major }}".
+	Value string `json:"value,omitempty"`
+}
+
+var Config = NFDConfig{}
+
+// Implement FeatureSource interface
+type Source struct{}
+
+func (s Source) Name() string { return "accelerator" }
+
+func (s Source) Discover() (source.Features, error) {
+	features := source.Features{}
+
+	devs, err := readPciDevices()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to detect PCI devices: %v", err)
+	}
+
+	for k, v := range discoverQat(devs) {
+		features[k] = v
+	}
+	for k, v := range discoverFpga(devs) {
+		features[k] = v
+	}
+	for k, v := range discoverGpu(devs) {
+		features[k] = v
+	}
+	for k, v := range discoverVpu(devs) {
+		features[k] = v
+	}
+	for k, v := range discoverHabana(devs) {
+		features[k] = v
+	}
+	for k, v := range discoverDevices(devs, Config.Devices) {
+		features[k] = v
+	}
+
+	return features, nil
+}
+
+// pciDevice holds the identification of one PCI device
+type pciDevice struct {
+	address string
+	vendor  string
+	device  string
+	class   string
+	// Name of the bound driver, empty if none
+	driver string
+}
+
+// Read the PCI devices of the node from sysfs
+func readPciDevices() ([]pciDevice, error) {
+	entries, err := ioutil.ReadDir(sysfsPciDevices)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	devs := make([]pciDevice, 0, len(entries))
+	for _, entry := range entries {
+		dev := pciDevice{address: entry.Name()}
+		attrs := map[string]*string{"vendor": &dev.vendor, "device": &dev.device, "class": &dev.class}
+		for attr, value := range attrs {
+			data, err := ioutil.ReadFile(path.Join(sysfsPciDevices, dev.address, attr))
+			if err != nil {
+				return nil, err
+			}
+			// Strip the 0x prefix, and the programming interface
+			// from the class
+			*value = strings.TrimPrefix(strings.TrimSpace(string(data)), "0x")
+		}
+		if len(dev.class) > 4 {
+			dev.class = dev.class[:4]
+		}
+		if driver, err := os.Readlink(path.Join(sysfsPciDevices, dev.address, "driver")); err == nil {
+			dev.driver = path.Base(driver)
+		}
+		devs = append(devs, dev)
+	}
+	return devs, nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accelerator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/kernelutils"
+	"sigs.k8s.io/node-feature-discovery/source/internal/labelutils"
+)
+
+// Valid label names, without the prefix of the source
+var labelNameRe = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+
+// UnmarshalJSON parses the configuration of the source, ignoring invalid
+// device rules with a warning, so that they are reported once when the
+// configuration is read.
+func (c *NFDConfig) UnmarshalJSON(data []byte) error {
+	type config NFDConfig
+	if err := json.Unmarshal(data, (*config)(c)); err != nil {
+		return err
+	}
+
+	valid := []DeviceRule{}
+	for _, rule := range c.Devices {
+		if err := validateDeviceRule(rule); err != nil {
+			logger.Printf("WARNING: invalid device rule %+v: %s, ignoring...", rule, err)
+			continue
+		}
+		valid = append(valid, rule)
+	}
+	c.Devices = valid
+	return nil
+}
+
+// validateDeviceRule checks that a device rule has at least one ID, that its
+// label is a valid label name of at most 63 characters, including the prefix
+// of the source, and that its value template is valid.
+func validateDeviceRule(rule DeviceRule) error {
+	if normalizeID(rule.Vendor)+normalizeID(rule.Device)+normalizeID(rule.Class) == "" {
+		return fmt.Errorf("at least one ID is required")
+	}
+	name := Source{}.Name() + "-" + rule.Label
+	if rule.Label == "" || !labelNameRe.MatchString(name) || len(name) > 63 {
+		return fmt.Errorf("invalid label name %q", name)
+	}
+	if _, err := template.New(rule.Label).Parse(rule.Value); err != nil {
+		return fmt.Errorf("invalid value template: %s", err)
+	}
+	return nil
+}
+
+// Detect the PCI devices configured by the administrator. Rules are
+// re-evaluated on every discovery, so that hot-plugged devices, and
+// configuration changes, are picked up. Rules are validated when the
+// configuration is parsed.
+func discoverDevices(devs []pciDevice, rules []DeviceRule) source.Features {
+	features := source.Features{}
+
+	for _, rule := range rules {
+		vendor, device, class := normalizeID(rule.Vendor), normalizeID(rule.Device), normalizeID(rule.Class)
+		var tmpl *template.Template
+		if rule.Value != "" {
+			var err error
+			tmpl, err = template.New(rule.Label).Option("missingkey=error").Parse(rule.Value)
+			if err != nil {
+				logger.Printf("WARNING: invalid value template of device rule %+v: %s, ignoring...", rule, err)
+				continue
+			}
+		}
+		for _, dev := range devs {
+			if (vendor == "" || dev.vendor == vendor) &&
+				(device == "" || dev.device == device) &&
+				strings.HasPrefix(dev.class, class) {
+				if tmpl == nil {
+					features[rule.Label] = true
+				} else if value, err := ruleValue(tmpl, dev); err != nil {
+					logger.Printf("WARNING: failed to evaluate value of device rule %+v: %s, ignoring...", rule, err)
+				} else {
+					features[rule.Label] = value
+				}
+				break
+			}
+		}
+	}
+
+	return features
+}
+
+// Evaluate the value template of a device rule. The template can refer to the
+// attributes of the matching device as .pci.<attribute>, and to the kernel
+// version as .kernel.version.<component>, like the features of the pci and
+// kernel sources.
+func ruleValue(tmpl *template.Template, dev pciDevice) (string, error) {
+	data := map[string]interface{}{
+		"pci": map[string]string{
+			"vendor": dev.vendor,
+			"device": dev.device,
+			"class":  dev.class,
+			"driver": dev.driver,
+		},
+		"kernel": map[string]interface{}{},
+	}
+	if version, err := kernelutils.ParseVersion(); err == nil {
+		data["kernel"] = map[string]interface{}{"version": version}
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return labelutils.Value(buf.String()), nil
+}
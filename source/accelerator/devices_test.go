@@ -0,0 +1,131 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accelerator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"text/template"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+var testDevices = []pciDevice{
+	{address: "0000:00:02.0", vendor: "8086", device: "3e92", class: "030000", driver: "i915"},
+	{address: "0000:3b:00.0", vendor: "10de", device: "20b0", class: "030200", driver: "nvidia"},
+	{address: "0000:00:06.0", vendor: "1d0f", device: "efa0", class: "020000"},
+}
+
+func TestRuleValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		expected string
+		err      bool
+	}{
+		{"Device attributes are substituted", "{{ .pci.vendor }}_{{ .pci.device }}", "10de_20b0", false},
+		{"Invalid characters are replaced with dashes", "{{ .pci.driver }} {{ .pci.class }}!", "nvidia-030200", false},
+		{"Missing device attributes are an error", "{{ .pci.revision }}", "", true},
+		{"Missing top-level keys are an error", "{{ .usb.vendor }}", "", true},
+	}
+
+	Convey("When evaluating the value template of a device rule", t, func() {
+		for _, test := range tests {
+			Convey(test.name, func() {
+				tmpl := template.Must(template.New("test").Option("missingkey=error").Parse(test.template))
+				value, err := ruleValue(tmpl, testDevices[1])
+				if test.err {
+					So(err, ShouldNotBeNil)
+				} else {
+					So(err, ShouldBeNil)
+					So(value, ShouldEqual, test.expected)
+				}
+			})
+		}
+	})
+}
+
+func TestDiscoverDevices(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    []DeviceRule
+		expected source.Features
+	}{
+		{
+			"Rules matching vendor and device IDs publish true",
+			[]DeviceRule{{Vendor: "1d0f", Device: "efa0", Label: "aws-efa.present"}},
+			source.Features{"aws-efa.present": true},
+		},
+		{
+			"Rules match IDs in any case and with a 0x prefix, and class prefixes",
+			[]DeviceRule{{Vendor: "0x10DE", Class: "03", Label: "nvidia.present"}},
+			source.Features{"nvidia.present": true},
+		},
+		{
+			"Rules not matching any device are not published",
+			[]DeviceRule{{Vendor: "1002", Label: "amd.present"}},
+			source.Features{},
+		},
+		{
+			"Templated values are evaluated for the first matching device",
+			[]DeviceRule{{Class: "03", Label: "gpu.driver", Value: "{{ .pci.driver }}"}},
+			source.Features{"gpu.driver": "i915"},
+		},
+		{
+			"Rules whose template refers to missing keys are ignored",
+			[]DeviceRule{{Class: "03", Label: "gpu.revision", Value: "{{ .pci.revision }}"}},
+			source.Features{},
+		},
+	}
+
+	Convey("When discovering the devices configured by device rules", t, func() {
+		for _, test := range tests {
+			Convey(test.name, func() {
+				So(discoverDevices(testDevices, test.rules), ShouldResemble, test.expected)
+			})
+		}
+	})
+}
+
+func TestParseDeviceRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		valid bool
+	}{
+		{"Rules with IDs and a label are valid", `{"vendor": "1d0f", "device": "efa0", "label": "aws-efa.present"}`, true},
+		{"Rules with a value template are valid", `{"class": "03", "label": "gpu.driver", "value": "{{ .pci.driver }}"}`, true},
+		{"Rules without IDs are ignored", `{"label": "any.present"}`, false},
+		{"Rules without a label are ignored", `{"vendor": "8086"}`, false},
+		{"Rules with an invalid label name are ignored", `{"vendor": "8086", "label": "intel/gpu"}`, false},
+		{"Rules with a label name over 63 characters are ignored", `{"vendor": "8086", "label": "` + strings.Repeat("x", 52) + `"}`, false},
+		{"Rules with an invalid template are ignored", `{"class": "03", "label": "gpu.driver", "value": "{{ .pci.driver"}`, false},
+	}
+
+	Convey("When parsing the device rules of the configuration", t, func() {
+		for _, test := range tests {
+			Convey(test.name, func() {
+				config := NFDConfig{}
+				err := json.Unmarshal([]byte(`{"devices": [`+test.rule+`]}`), &config)
+				So(err, ShouldBeNil)
+				So(len(config.Devices) == 1, ShouldEqual, test.valid)
+			})
+		}
+	})
+}
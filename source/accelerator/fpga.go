@@ -0,0 +1,144 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accelerator
+
+import (
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/labelutils"
+)
+
+// sysfs attributes of Intel FPGAs, managed by the OPAE or the upstream DFL
+// (Device Feature List) drivers
+const (
+	// Interface ID of the partial reconfiguration region, i.e. the FPGA
+	// interface manager (FIM) that accelerator functions are built for
+	opaeInterfaceIDGlob = "/sys/class/fpga/intel-fpga-dev.*/intel-fpga-fme.*/pr/interface_id"
+	dflInterfaceIDGlob  = "/sys/class/fpga_region/region*/dfl-fme.*/dfl-fme-region.*/fpga_region/region*/compat_id"
+	// ID of the accelerator function unit (AFU) programmed on a port
+	opaeAfuIDGlob = "/sys/class/fpga/intel-fpga-dev.*/intel-fpga-port.*/afu_id"
+	dflAfuIDGlob  = "/sys/class/fpga_region/region*/dfl-port.*/afu_id"
+)
+
+// Xilinx FPGA drivers of XRT
+var xrtDrivers = map[string]bool{
+	"xclmgmt": true,
+	"xocl":    true,
+}
+
+// Maximum length of a shell name, so that the label name, i.e.
+// accelerator-fpga.shell.<name>, does not exceed 63 characters
+const maxShellNameLen = 63 - len("accelerator-fpga.shell.")
+
+// Detect FPGAs, and the shells and accelerator functions programmed on them
+func discoverFpga(devs []pciDevice) source.Features {
+	features := source.Features{}
+
+	devices := 0
+	interfaces := map[string]bool{}
+	afus := map[string]bool{}
+
+	// Intel FPGAs
+	for _, glob := range []string{opaeInterfaceIDGlob, dflInterfaceIDGlob} {
+		for _, id := range readAttrs(glob) {
+			devices++
+			interfaces[normalizeID(id)] = true
+		}
+	}
+	for _, glob := range []string{opaeAfuIDGlob, dflAfuIDGlob} {
+		for _, id := range readAttrs(glob) {
+			afus[normalizeID(id)] = true
+		}
+	}
+
+	// Xilinx FPGAs. The management and user functions of a board are
+	// bound to different drivers, count only the management functions.
+	shells := map[string]bool{}
+	for _, dev := range devs {
+		if !xrtDrivers[dev.driver] {
+			continue
+		}
+		devPath := path.Join(sysfsPciDevices, dev.address)
+		if dev.driver == "xclmgmt" {
+			devices++
+		}
+		// Name of the shell (platform), e.g.
+		// xilinx_u250_gen3x16_xdma_shell_3_1
+		for _, vbnv := range readAttrs(path.Join(devPath, "rom.*", "VBNV")) {
+			// The VBNV is a free-form string read from the
+			// board, so it needs to be turned into a valid name
+			shell := labelutils.Value(vbnv)
+			if shell == "" || len(shell) > maxShellNameLen {
+				logger.Printf("WARNING: shell name %q of FPGA %s is not usable in a label name, ignoring...", vbnv, dev.address)
+				continue
+			}
+			shells[shell] = true
+		}
+		// UUID of the loaded xclbin, i.e. the accelerator function
+		for _, uuid := range readAttrs(path.Join(devPath, "xclbinuuid")) {
+			if id := normalizeID(uuid); strings.Trim(id, "0") != "" {
+				afus[id] = true
+			}
+		}
+	}
+
+	if devices == 0 && len(shells) == 0 {
+		return features
+	}
+	features["fpga.present"] = true
+	if devices > 0 {
+		features["fpga.devices"] = devices
+	}
+	for id := range interfaces {
+		features["fpga.interface."+id] = true
+	}
+	for shell := range shells {
+		features["fpga.shell."+shell] = true
+	}
+	for id := range afus {
+		features["fpga.afu."+id] = true
+	}
+
+	return features
+}
+
+// Read the values of all sysfs attributes matching a glob pattern
+func readAttrs(glob string) []string {
+	files, _ := filepath.Glob(glob)
+	values := []string{}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			logger.Printf("failed to read %s: %s", file, err)
+			continue
+		}
+		if value := strings.TrimSpace(string(data)); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Normalize an ID or UUID into lowercase hex digits only
+func normalizeID(id string) string {
+	id = strings.TrimPrefix(strings.ToLower(id), "0x")
+	return strings.Replace(id, "-", "", -1)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accelerator
+
+import (
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/pciutils"
+)
+
+// GPU vendors, by PCI vendor ID. Display controllers of other vendors, e.g.
+// the VGA of a BMC, are not considered GPUs.
+var gpuVendors = map[string]string{
+	"10de": "nvidia",
+	"1002": "amd",
+	"8086": "intel",
+}
+
+// Detect GPUs, i.e. display controller class PCI devices of known GPU
+// vendors
+func discoverGpu(devs []pciDevice) source.Features {
+	features := source.Features{}
+
+	total := 0
+	for _, dev := range gpuDevices(devs) {
+		vendor := gpuVendors[dev.vendor]
+		prefix := "gpu." + vendor + "."
+		if _, ok := features[prefix+"present"]; !ok {
+			features[prefix+"present"] = true
+			features[prefix+"devices"] = 0
+		}
+		features[prefix+"devices"] = features[prefix+"devices"].(int) + 1
+		if pciutils.GpuReady(dev.address, dev.driver) {
+			features[prefix+"driver_ready"] = true
+		}
+		total++
+	}
+	if total == 0 {
+		return features
+	}
+	features["gpu.present"] = true
+	features["gpu.devices"] = total
+
+	if nvidia := vendorGpus(devs, "10de"); len(nvidia) > 0 {
+		for k, v := range discoverNvidiaGpu(nvidia) {
+			features[k] = v
+		}
+	}
+	if amd := vendorGpus(devs, "1002"); len(amd) > 0 {
+		for k, v := range discoverAmdGpu(amd) {
+			features[k] = v
+		}
+	}
+	if intel := vendorGpus(devs, "8086"); len(intel) > 0 {
+		for k, v := range discoverIntelGpu(intel) {
+			features[k] = v
+		}
+	}
+
+	return features
+}
+
+// Filter the GPUs from a list of PCI devices. Class 03 covers VGA compatible,
+// 3D and other display controllers.
+func gpuDevices(devs []pciDevice) []pciDevice {
+	gpus := []pciDevice{}
+	for _, dev := range devs {
+		if _, ok := gpuVendors[dev.vendor]; ok && strings.HasPrefix(dev.class, "03") {
+			gpus = append(gpus, dev)
+		}
+	}
+	return gpus
+}
+
+// Filter the GPUs of one vendor from a list of PCI devices
+func vendorGpus(devs []pciDevice, vendor string) []pciDevice {
+	gpus := []pciDevice{}
+	for _, dev := range gpuDevices(devs) {
+		if dev.vendor == vendor {
+			gpus = append(gpus, dev)
+		}
+	}
+	return gpus
+}
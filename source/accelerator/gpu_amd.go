@@ -0,0 +1,98 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accelerator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+// Topology of the compute nodes, i.e. CPUs and GPUs, exposed by the AMD
+// kernel fusion driver (KFD) that ROCm uses
+const sysfsKfdNodes = "/sys/class/kfd/kfd/topology/nodes"
+
+// Detect the details of AMD GPUs: the GFX version and VRAM size of the first
+// GPU
+func discoverAmdGpu(gpus []pciDevice) source.Features {
+	features := source.Features{}
+
+	if gfx := amdGfxVersion(); gfx != "" {
+		features["gpu.amd.gfx_version"] = gfx
+	}
+	for _, gpu := range gpus {
+		if gpu.driver != "amdgpu" {
+			continue
+		}
+		data, err := ioutil.ReadFile(path.Join(sysfsPciDevices, gpu.address, "mem_info_vram_total"))
+		if err != nil {
+			continue
+		}
+		vram, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			logger.Printf("failed to parse VRAM size of %s: %s", gpu.address, err)
+			continue
+		}
+		features["gpu.amd.memory"] = vram >> 20
+		break
+	}
+
+	return features
+}
+
+// Get the GFX version, i.e. the ISA, of the first GPU known to KFD, e.g.
+// "gfx908" for MI100 or "gfx90a" for MI200 GPUs
+func amdGfxVersion() string {
+	nodes, err := ioutil.ReadDir(sysfsKfdNodes)
+	if err != nil {
+		return ""
+	}
+	ids := []int{}
+	for _, node := range nodes {
+		if id, err := strconv.Atoi(node.Name()); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		data, err := ioutil.ReadFile(path.Join(sysfsKfdNodes, strconv.Itoa(id), "properties"))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) != 2 || fields[0] != "gfx_target_version" {
+				continue
+			}
+			// Decimal major*10000 + minor*100 + stepping, zero for
+			// CPU nodes. Minor and stepping are printed in hex in
+			// the GFX version.
+			version, err := strconv.Atoi(fields[1])
+			if err != nil || version == 0 {
+				break
+			}
+			return fmt.Sprintf("gfx%d%x%x", version/10000, version/100%100, version%100)
+		}
+	}
+	return ""
+}
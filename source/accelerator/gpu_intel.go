@@ -0,0 +1,86 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accelerator
+
+import (
+	"sync"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+// Families of discrete Intel GPUs, by PCI device ID. Other Intel GPUs are
+// integrated into the CPU.
+var intelGpuFamilies = map[string]string{
+	// Iris Xe MAX and Server GPU (DG1)
+	"4905": "dg1", "4906": "dg1", "4907": "dg1", "4908": "dg1",
+	// Arc A-series (Alchemist)
+	"5690": "arc", "5691": "arc", "5692": "arc", "5693": "arc",
+	"5694": "arc", "5695": "arc", "5696": "arc", "5697": "arc",
+	"56a0": "arc", "56a1": "arc", "56a2": "arc", "56a3": "arc",
+	"56a4": "arc", "56a5": "arc", "56a6": "arc", "56b0": "arc",
+	"56b1": "arc", "56b2": "arc", "56b3": "arc", "56ba": "arc",
+	"56bb": "arc", "56bc": "arc", "56bd": "arc",
+	// Arc B-series (Battlemage)
+	"e202": "arc", "e209": "arc", "e20b": "arc", "e20c": "arc",
+	"e20d": "arc", "e210": "arc", "e212": "arc", "e215": "arc",
+	"e216": "arc",
+	// Data Center GPU Flex series
+	"56c0": "flex", "56c1": "flex", "56c2": "flex",
+	// Data Center GPU Max series (Ponte Vecchio)
+	"0b69": "max", "0b6e": "max", "0bd0": "max", "0bd4": "max",
+	"0bd5": "max", "0bd6": "max", "0bd7": "max", "0bd8": "max",
+	"0bd9": "max", "0bda": "max", "0bdb": "max",
+}
+
+// Failing to query the EU count is warned about only once, as it would
+// fail the same way on every labeling pass
+var i915EuTotalWarning sync.Once
+
+// Detect the details of Intel GPUs: the family and the number of execution
+// units (EUs) of the first discrete GPU, or of the integrated GPU if there
+// are no discrete GPUs
+func discoverIntelGpu(gpus []pciDevice) source.Features {
+	features := source.Features{}
+
+	gpu := gpus[0]
+	family := "integrated"
+	for _, dev := range gpus {
+		if f, ok := intelGpuFamilies[dev.device]; ok {
+			gpu, family = dev, f
+			break
+		}
+	}
+	features["gpu.intel.family"] = family
+	if gpu.driver == "i915" || gpu.driver == "xe" {
+		features["gpu.intel.driver"] = gpu.driver
+	}
+
+	// The EU count is only reported by i915, through the render node of
+	// the GPU
+	if gpu.driver == "i915" {
+		eus, err := i915EuTotal(gpu.address)
+		if err != nil {
+			i915EuTotalWarning.Do(func() {
+				logger.Printf("WARNING: failed to get the EU count of %s: %s", gpu.address, err)
+			})
+		} else if eus > 0 {
+			features["gpu.intel.eus"] = eus
+		}
+	}
+
+	return features
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accelerator
+
+import (
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/labelutils"
+)
+
+// procfs interface of the proprietary NVIDIA driver
+const procNvidia = "/proc/driver/nvidia"
+
+var nvidiaVersionRe = regexp.MustCompile(`^[0-9]+\.[0-9]+(\.[0-9]+)?$`)
+
+// Nodes without the NVML library, and builds without cgo, fall back to procfs
+// on every labeling pass, so the fallback is only warned about once
+var nvmlFallbackWarning sync.Once
+
+// Detect the details of NVIDIA GPUs: the model, memory size and compute
+// capability of the first GPU, and the version of the driver. The details
+// are queried from NVML, falling back to the procfs interface of the driver,
+// which does not report the memory size and compute capability.
+func discoverNvidiaGpu(gpus []pciDevice) source.Features {
+	features := source.Features{}
+
+	driver, devices, err := nvmlQuery()
+	if err == nil {
+		if len(devices) > 0 {
+			features["gpu.nvidia.model"] = labelutils.Value(devices[0].name)
+			features["gpu.nvidia.memory"] = devices[0].memory >> 20
+			features["gpu.nvidia.compute_capability"] = devices[0].computeCapability
+		}
+		for k, v := range discoverMig(devices) {
+			features[k] = v
+		}
+	} else {
+		nvmlFallbackWarning.Do(func() {
+			logger.Printf("WARNING: failed to query NVML, falling back to %s: %s", procNvidia, err)
+		})
+		driver = nvidiaProcDriverVersion()
+		for _, gpu := range gpus {
+			if model := nvidiaProcModel(gpu.address); model != "" {
+				features["gpu.nvidia.model"] = labelutils.Value(model)
+				break
+			}
+		}
+	}
+	if driver != "" {
+		features["gpu.nvidia.driver"] = driver
+	}
+
+	return features
+}
+
+// Detect Multi-Instance GPU (MIG) support. The MIG strategy is derived from
+// the MIG modes of the GPUs, the same way as the NVIDIA device plugin
+// expects it to be configured: "single" if MIG is enabled on all GPUs,
+// "mixed" if MIG is enabled on some of them, and "none" otherwise.
+func discoverMig(devices []nvmlDevice) source.Features {
+	features := source.Features{}
+
+	capable, enabled := 0, 0
+	for _, dev := range devices {
+		if dev.migCapable {
+			capable++
+		}
+		if dev.migEnabled {
+			enabled++
+		}
+	}
+	if capable == 0 {
+		return features
+	}
+
+	features["gpu.mig.capable"] = true
+	switch enabled {
+	case 0:
+		features["gpu.mig.strategy"] = "none"
+	case len(devices):
+		features["gpu.mig.strategy"] = "single"
+	default:
+		features["gpu.mig.strategy"] = "mixed"
+	}
+
+	return features
+}
+
+// Read the driver version from the procfs interface of the NVIDIA driver
+func nvidiaProcDriverVersion() string {
+	data, err := ioutil.ReadFile(path.Join(procNvidia, "version"))
+	if err != nil {
+		return ""
+	}
+	// E.g. "NVRM version: NVIDIA UNIX x86_64 Kernel Module  418.67  ..."
+	lines := strings.SplitN(string(data), "\n", 2)
+	for _, field := range strings.Fields(lines[0]) {
+		if nvidiaVersionRe.MatchString(field) {
+			return field
+		}
+	}
+	return ""
+}
+
+// Read the model name of one GPU from the procfs interface of the NVIDIA
+// driver
+func nvidiaProcModel(address string) string {
+	data, err := ioutil.ReadFile(path.Join(procNvidia, "gpus", address, "information"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		split := strings.SplitN(line, ":", 2)
+		if len(split) == 2 && strings.TrimSpace(split[0]) == "Model" {
+			return strings.TrimSpace(split[1])
+		}
+	}
+	return ""
+}
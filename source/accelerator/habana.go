@@ -0,0 +1,85 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accelerator
+
+import (
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/labelutils"
+)
+
+// Habana Labs AI training processors, by PCI device ID of the Habana vendor
+// ID (1da3)
+var habanaModels = map[string]string{
+	"1000": "gaudi",
+	"1010": "gaudi", // Secured Gaudi
+	"1020": "gaudi2",
+}
+
+// Detect Habana Gaudi AI training processors
+func discoverHabana(devs []pciDevice) source.Features {
+	features := source.Features{}
+
+	count := 0
+	for _, dev := range devs {
+		model, ok := habanaModels[dev.device]
+		if !ok || dev.vendor != "1da3" {
+			continue
+		}
+		if count == 0 {
+			features["habana.model"] = model
+			if dev.driver == "habanalabs" {
+				if fw := habanaFirmwareVersion(dev.address); fw != "" {
+					features["habana.firmware"] = labelutils.Value(fw)
+				}
+			}
+		}
+		count++
+	}
+	if count == 0 {
+		return features
+	}
+	features["habana.present"] = true
+	features["habana.devices"] = count
+
+	return features
+}
+
+// Read the version of the firmware running on the embedded CPU of a device.
+// Depending on the kernel version, habanalabs devices are registered in the
+// habanalabs or the accel class, and the firmware is called CPU-CP or ArmCP.
+func habanaFirmwareVersion(address string) string {
+	for _, class := range []string{"habanalabs/hl*", "accel/accel*"} {
+		for _, attr := range []string{"cpucp_ver", "armcp_ver"} {
+			files, _ := filepath.Glob(path.Join(sysfsPciDevices, address, class, attr))
+			for _, file := range files {
+				data, err := ioutil.ReadFile(file)
+				if err != nil {
+					continue
+				}
+				if version := strings.TrimSpace(string(data)); version != "" {
+					return version
+				}
+			}
+		}
+	}
+	return ""
+}
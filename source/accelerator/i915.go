@@ -0,0 +1,73 @@
+// +build linux
+
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accelerator
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// ioctl of the i915 driver, from drm/i915_drm.h
+	DRM_IOCTL_BASE      = 'd'
+	DRM_COMMAND_BASE    = 0x40
+	DRM_I915_GETPARAM   = 0x06
+	I915_PARAM_EU_TOTAL = 34
+	IOC_READ_WRITE      = 3
+	IOC_SIZESHIFT       = 16
+	IOC_DIRSHIFT        = 30
+	IOC_TYPESHIFT       = 8
+)
+
+// struct drm_i915_getparam
+type i915Getparam struct {
+	param int32
+	value *int32
+}
+
+// Get the total number of EUs of a GPU driven by i915. Zero is returned if
+// the render node of the GPU is not available, e.g. because /dev/dri is not
+// exposed to the container.
+func i915EuTotal(address string) (int, error) {
+	nodes, _ := filepath.Glob(path.Join(sysfsPciDevices, address, "drm", "renderD*"))
+	if len(nodes) == 0 {
+		return 0, nil
+	}
+	f, err := os.OpenFile(path.Join("/dev/dri", path.Base(nodes[0])), os.O_RDWR, 0)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	value := new(int32)
+	gp := i915Getparam{param: I915_PARAM_EU_TOTAL, value: value}
+	req := uintptr(IOC_READ_WRITE<<IOC_DIRSHIFT | unsafe.Sizeof(gp)<<IOC_SIZESHIFT |
+		DRM_IOCTL_BASE<<IOC_TYPESHIFT | (DRM_COMMAND_BASE + DRM_I915_GETPARAM))
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), req, uintptr(unsafe.Pointer(&gp)))
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(*value), nil
+}
@@ -0,0 +1,201 @@
+// +build cgo
+
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accelerator
+
+/*
+#cgo LDFLAGS: -ldl
+#include <dlfcn.h>
+
+// NVML is loaded at runtime, so that nodes without the NVIDIA driver, and
+// thus without the library, are supported
+
+#define NVML_ERROR_NOT_SUPPORTED 3
+#define NVML_ERROR_LIBRARY_NOT_FOUND 12
+#define NVML_ERROR_FUNCTION_NOT_FOUND 13
+
+typedef void *nvmlDevice_t;
+
+typedef struct {
+	unsigned long long total;
+	unsigned long long free;
+	unsigned long long used;
+} nvmlMemory_t;
+
+static void *nvml;
+
+#define NVML_FUNC(name, ...) \
+	int (*fn)(__VA_ARGS__) = nvml ? dlsym(nvml, name) : NULL; \
+	if (!fn) \
+		return NVML_ERROR_FUNCTION_NOT_FOUND
+
+static int nvml_init(void) {
+	int (*fn)(void);
+	int ret;
+
+	nvml = dlopen("libnvidia-ml.so.1", RTLD_LAZY);
+	if (!nvml)
+		return NVML_ERROR_LIBRARY_NOT_FOUND;
+	fn = dlsym(nvml, "nvmlInit_v2");
+	ret = fn ? fn() : NVML_ERROR_FUNCTION_NOT_FOUND;
+	// nvml_shutdown() is not called if initialization fails
+	if (ret != 0) {
+		dlclose(nvml);
+		nvml = NULL;
+	}
+	return ret;
+}
+
+static int nvml_shutdown(void) {
+	int ret;
+	NVML_FUNC("nvmlShutdown", void);
+	ret = fn();
+	dlclose(nvml);
+	nvml = NULL;
+	return ret;
+}
+
+static int nvml_driver_version(char *version, unsigned int len) {
+	NVML_FUNC("nvmlSystemGetDriverVersion", char *, unsigned int);
+	return fn(version, len);
+}
+
+static int nvml_device_count(unsigned int *count) {
+	NVML_FUNC("nvmlDeviceGetCount_v2", unsigned int *);
+	return fn(count);
+}
+
+static int nvml_device_handle(unsigned int index, nvmlDevice_t *dev) {
+	NVML_FUNC("nvmlDeviceGetHandleByIndex_v2", unsigned int, nvmlDevice_t *);
+	return fn(index, dev);
+}
+
+static int nvml_device_name(nvmlDevice_t dev, char *name, unsigned int len) {
+	NVML_FUNC("nvmlDeviceGetName", nvmlDevice_t, char *, unsigned int);
+	return fn(dev, name, len);
+}
+
+static int nvml_device_memory(nvmlDevice_t dev, unsigned long long *total) {
+	nvmlMemory_t memory;
+	int ret;
+	NVML_FUNC("nvmlDeviceGetMemoryInfo", nvmlDevice_t, nvmlMemory_t *);
+	ret = fn(dev, &memory);
+	*total = memory.total;
+	return ret;
+}
+
+static int nvml_device_compute_capability(nvmlDevice_t dev, int *major, int *minor) {
+	NVML_FUNC("nvmlDeviceGetCudaComputeCapability", nvmlDevice_t, int *, int *);
+	return fn(dev, major, minor);
+}
+
+static int nvml_device_mig_mode(nvmlDevice_t dev, unsigned int *current, unsigned int *pending) {
+	NVML_FUNC("nvmlDeviceGetMigMode", nvmlDevice_t, unsigned int *, unsigned int *);
+	return fn(dev, current, pending);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+)
+
+// Maximum length of the strings returned by NVML, from nvml.h
+const (
+	nvmlDeviceNameBufferSize          = 96
+	nvmlSystemDriverVersionBufferSize = 80
+)
+
+// nvmlDevice holds the details of one NVIDIA GPU, as reported by NVML
+type nvmlDevice struct {
+	name string
+	// Total memory, in bytes
+	memory uint64
+	// CUDA compute capability, i.e. "<major>.<minor>"
+	computeCapability string
+	// Multi-Instance GPU (MIG) support, and the current MIG mode
+	migCapable bool
+	migEnabled bool
+}
+
+func nvmlError(function string, ret C.int) error {
+	return fmt.Errorf("%s failed: NVML error %d", function, int(ret))
+}
+
+// Query the driver version, and the details of all GPUs, from the NVIDIA
+// Management Library (NVML)
+func nvmlQuery() (string, []nvmlDevice, error) {
+	if ret := C.nvml_init(); ret != 0 {
+		return "", nil, nvmlError("nvmlInit", ret)
+	}
+	defer C.nvml_shutdown()
+
+	version := make([]C.char, nvmlSystemDriverVersionBufferSize)
+	if ret := C.nvml_driver_version(&version[0], C.uint(len(version))); ret != 0 {
+		return "", nil, nvmlError("nvmlSystemGetDriverVersion", ret)
+	}
+	driver := C.GoString(&version[0])
+
+	var count C.uint
+	if ret := C.nvml_device_count(&count); ret != 0 {
+		return "", nil, nvmlError("nvmlDeviceGetCount", ret)
+	}
+
+	devices := make([]nvmlDevice, 0, int(count))
+	for i := C.uint(0); i < count; i++ {
+		var handle C.nvmlDevice_t
+		if ret := C.nvml_device_handle(i, &handle); ret != 0 {
+			return "", nil, nvmlError("nvmlDeviceGetHandleByIndex", ret)
+		}
+
+		dev := nvmlDevice{}
+		name := make([]C.char, nvmlDeviceNameBufferSize)
+		if ret := C.nvml_device_name(handle, &name[0], C.uint(len(name))); ret != 0 {
+			return "", nil, nvmlError("nvmlDeviceGetName", ret)
+		}
+		dev.name = C.GoString(&name[0])
+
+		var memory C.ulonglong
+		if ret := C.nvml_device_memory(handle, &memory); ret != 0 {
+			return "", nil, nvmlError("nvmlDeviceGetMemoryInfo", ret)
+		}
+		dev.memory = uint64(memory)
+
+		var major, minor C.int
+		if ret := C.nvml_device_compute_capability(handle, &major, &minor); ret != 0 {
+			return "", nil, nvmlError("nvmlDeviceGetCudaComputeCapability", ret)
+		}
+		dev.computeCapability = fmt.Sprintf("%d.%d", int(major), int(minor))
+
+		// GPUs, and drivers, without MIG support do not report a MIG
+		// mode
+		var current, pending C.uint
+		switch ret := C.nvml_device_mig_mode(handle, &current, &pending); ret {
+		case 0:
+			dev.migCapable = true
+			dev.migEnabled = current != 0
+		case C.NVML_ERROR_NOT_SUPPORTED, C.NVML_ERROR_FUNCTION_NOT_FOUND:
+		default:
+			return "", nil, nvmlError("nvmlDeviceGetMigMode", ret)
+		}
+
+		devices = append(devices, dev)
+	}
+
+	return driver, devices, nil
+}
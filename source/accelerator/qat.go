@@ -0,0 +1,97 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accelerator
+
+import (
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/pciutils"
+)
+
+// qatDevice describes one Intel QuickAssist Technology device model
+type qatDevice struct {
+	generation int
+	// Virtual function, i.e. SR-IOV VF of a physical QAT device
+	vf bool
+}
+
+// Intel QuickAssist devices, by PCI device ID
+var qatDevices = map[string]qatDevice{
+	"0435": {1, false}, // DH895xCC
+	"0443": {1, true},
+	"37c8": {2, false}, // C62x
+	"37c9": {2, true},
+	"19e2": {2, false}, // C3xxx
+	"19e3": {2, true},
+	"6f54": {2, false}, // D15xx
+	"6f55": {2, true},
+	"18a0": {3, false}, // C4xxx
+	"18a1": {3, true},
+	"4940": {4, false}, // 4xxx
+	"4941": {4, true},
+	"4942": {4, false}, // 401xx
+	"4943": {4, true},
+	"4944": {4, false}, // 402xx
+	"4945": {4, true},
+	"4946": {4, false}, // 420xx
+	"4947": {4, true},
+}
+
+// Detect Intel QuickAssist Technology (QAT) crypto and compression
+// accelerators
+func discoverQat(devs []pciDevice) source.Features {
+	features := source.Features{}
+
+	pfs, vfs, generation := 0, 0, 0
+	ready := false
+	for _, dev := range devs {
+		if dev.vendor != "8086" {
+			continue
+		}
+		qat, ok := qatDevices[dev.device]
+		if !ok {
+			continue
+		}
+		if qat.vf {
+			vfs++
+		} else {
+			pfs++
+		}
+		if qat.generation > generation {
+			generation = qat.generation
+		}
+		if pciutils.QatReady(dev.address, dev.driver) {
+			ready = true
+		}
+	}
+	if pfs+vfs == 0 {
+		return features
+	}
+
+	features["qat.present"] = true
+	features["qat.generation"] = generation
+	if pfs > 0 {
+		features["qat.devices"] = pfs
+	}
+	if vfs > 0 {
+		features["qat.vfs"] = vfs
+	}
+	if ready {
+		features["qat.driver_ready"] = true
+	}
+
+	return features
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accelerator
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+const sysfsUsbDevices = "/sys/bus/usb/devices"
+
+// Intel Movidius VPUs connected over USB, by USB product ID of the Movidius
+// vendor ID (03e7). Once the firmware has been booted, Myriad 2 and Myriad X
+// devices re-enumerate with the same product ID.
+var usbVpuModels = map[string]string{
+	"2150": "myriad2",
+	"2485": "myriadx",
+	"f63b": "myriad",
+}
+
+// Intel VPUs connected over PCIe, by PCI device ID
+var pciVpuModels = map[string]string{
+	"6200": "myriadx",
+	"6240": "keembay",
+}
+
+// Detect Intel Movidius vision processing units (VPUs)
+func discoverVpu(devs []pciDevice) source.Features {
+	features := source.Features{}
+
+	models := []string{}
+	for _, dev := range devs {
+		if model, ok := pciVpuModels[dev.device]; ok && dev.vendor == "8086" {
+			models = append(models, model)
+		}
+	}
+	for _, product := range usbProducts("03e7") {
+		if model, ok := usbVpuModels[product]; ok {
+			models = append(models, model)
+		}
+	}
+	if len(models) == 0 {
+		return features
+	}
+
+	features["vpu.present"] = true
+	features["vpu.devices"] = len(models)
+	for _, model := range models {
+		features["vpu."+model+".present"] = true
+	}
+
+	return features
+}
+
+// List the product IDs of the USB devices of one vendor
+func usbProducts(vendor string) []string {
+	entries, err := ioutil.ReadDir(sysfsUsbDevices)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Printf("failed to list USB devices: %s", err)
+		}
+		return nil
+	}
+
+	products := []string{}
+	for _, entry := range entries {
+		devPath := path.Join(sysfsUsbDevices, entry.Name())
+		// Interfaces of the devices are listed, too, without IDs
+		data, err := ioutil.ReadFile(path.Join(devPath, "idVendor"))
+		if err != nil || strings.TrimSpace(string(data)) != vendor {
+			continue
+		}
+		if data, err := ioutil.ReadFile(path.Join(devPath, "idProduct")); err == nil {
+			products = append(products, strings.TrimSpace(string(data)))
+		}
+	}
+	return products
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpu
+
+import (
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/cpuidutils"
+)
+
+const (
+	// CPUID EAX input values
+	LEAF_FEATURE_INFO = 0x01
+
+	// CPUID bitmasks
+	FEATURE_INFO_ECX_OSXSAVE       = 1 << 27
+	EXT_FEATURE_FLAGS_EDX_AMX_BF16 = 1 << 22
+	EXT_FEATURE_FLAGS_EDX_AMX_TILE = 1 << 24
+	EXT_FEATURE_FLAGS_EDX_AMX_INT8 = 1 << 25
+
+	// XCR0 bitmasks of the AMX state components
+	XCR0_XTILECFG  = 1 << 17
+	XCR0_XTILEDATA = 1 << 18
+
+	// arch_prctl() code to get the extended state components that processes
+	// may request permission for, see arch/x86/include/uapi/asm/prctl.h
+	ARCH_GET_XCOMP_SUPP = 0x1021
+)
+
+// Whether AMX is usable doesn't change while the node is up, so the reason for
+// AMX being unusable is only reported on the first pass
+var amxUnusableLog sync.Once
+
+// Detect Intel Advanced Matrix Extensions (AMX). AMX is usable only if the
+// OS has enabled saving of the tile state, which is reported in XCR0, and
+// lets processes request permission for the tile data with
+// arch_prctl(ARCH_REQ_XCOMP_PERM).
+func discoverAMX() source.Features {
+	features := source.Features{}
+
+	if cpuidutils.Cpuid(LEAF_BASIC_INFO, 0).EAX < LEAF_EXT_FEATURE_FLAGS {
+		return features
+	}
+
+	extFeatures := cpuidutils.Cpuid(LEAF_EXT_FEATURE_FLAGS, 0)
+	if extFeatures.EDX&EXT_FEATURE_FLAGS_EDX_AMX_TILE == 0 {
+		return features
+	}
+
+	if cpuidutils.Cpuid(LEAF_FEATURE_INFO, 0).ECX&FEATURE_INFO_ECX_OSXSAVE == 0 {
+		return features
+	}
+	xcr0 := cpuidutils.Xgetbv(0)
+	if xcr0&(XCR0_XTILECFG|XCR0_XTILEDATA) != XCR0_XTILECFG|XCR0_XTILEDATA {
+		amxUnusableLog.Do(func() {
+			logger.Printf("AMX is supported by the CPU but the tile state is not enabled by the OS")
+		})
+		return features
+	}
+	var supported uint64
+	_, _, errno := syscall.Syscall(syscall.SYS_ARCH_PRCTL, ARCH_GET_XCOMP_SUPP, uintptr(unsafe.Pointer(&supported)), 0)
+	if errno != 0 || supported&XCR0_XTILEDATA == 0 {
+		amxUnusableLog.Do(func() {
+			logger.Printf("AMX is supported by the CPU but the kernel does not grant the tile data to processes")
+		})
+		return features
+	}
+
+	features["amx.tile"] = true
+	if extFeatures.EDX&EXT_FEATURE_FLAGS_EDX_AMX_BF16 != 0 {
+		features["amx.bf16"] = true
+	}
+	if extFeatures.EDX&EXT_FEATURE_FLAGS_EDX_AMX_INT8 != 0 {
+		features["amx.int8"] = true
+	}
+
+	return features
+}
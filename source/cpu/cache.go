@@ -0,0 +1,93 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpu
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+// Detect the total size of the L2 and L3 caches of one socket, in KiB
+func discoverCaches() (source.Features, error) {
+	features := source.Features{}
+
+	socket, err := readTrimmed(filepath.Join(sysfsCpuDir, "cpu0/topology/physical_package_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	cpus, err := filepath.Glob(filepath.Join(sysfsCpuDir, "cpu[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Caches shared by multiple CPUs are listed under each of them, so
+	// identify cache instances by their level and the CPUs sharing them
+	seen := map[string]bool{}
+	sizes := map[string]uint64{}
+	for _, cpu := range cpus {
+		if pkg, err := readTrimmed(filepath.Join(cpu, "topology/physical_package_id")); err != nil || pkg != socket {
+			continue
+		}
+		caches, _ := filepath.Glob(filepath.Join(cpu, "cache/index[0-9]*"))
+		for _, cache := range caches {
+			level, err := readTrimmed(filepath.Join(cache, "level"))
+			if err != nil || (level != "2" && level != "3") {
+				continue
+			}
+			if t, _ := readTrimmed(filepath.Join(cache, "type")); t == "Instruction" {
+				continue
+			}
+			shared, err := readTrimmed(filepath.Join(cache, "shared_cpu_list"))
+			if err != nil {
+				continue
+			}
+			if seen[level+"/"+shared] {
+				continue
+			}
+			seen[level+"/"+shared] = true
+
+			size, err := readTrimmed(filepath.Join(cache, "size"))
+			if err != nil {
+				continue
+			}
+			kib, err := strconv.ParseUint(strings.TrimSuffix(size, "K"), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cache size %q", size)
+			}
+			sizes["cache.l"+level] += kib
+		}
+	}
+
+	for k, v := range sizes {
+		features[k] = v
+	}
+	return features, nil
+}
+
+func readTrimmed(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
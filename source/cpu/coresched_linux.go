@@ -0,0 +1,40 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpu
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// prctl options, from linux/prctl.h
+	PR_SCHED_CORE     = 62
+	PR_SCHED_CORE_GET = 0
+	PIDTYPE_PID       = 0
+)
+
+// Check if core scheduling is usable, i.e. the kernel was built with
+// CONFIG_SCHED_CORE and SMT is present
+func coreSchedulingSupported() bool {
+	var cookie uint64
+
+	// Fails with EINVAL if core scheduling is not supported by the kernel,
+	// and with ENODEV if SMT is not present
+	_, _, errno := syscall.Syscall6(syscall.SYS_PRCTL, PR_SCHED_CORE, PR_SCHED_CORE_GET, 0, PIDTYPE_PID, uintptr(unsafe.Pointer(&cookie)), 0)
+	return errno == 0
+}
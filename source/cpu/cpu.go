@@ -19,11 +19,16 @@ package cpu
 import (
 	"fmt"
 	"io/ioutil"
+	"log"
+	"os"
 	"path"
+	"strings"
 
 	"sigs.k8s.io/node-feature-discovery/source"
 )
 
+var logger = log.New(os.Stderr, "", log.LstdFlags)
+
 // Implement FeatureSource interface
 type Source struct{}
 
@@ -39,6 +44,83 @@ func (s Source) Discover() (source.Features, error) {
 	} else if found {
 		features["hardware_multithreading"] = true
 	}
+
+	// Count the sockets, cores and threads
+	topology, err := discoverTopology()
+	if err != nil {
+		logger.Printf("ERROR: Failed to detect CPU topology: %v", err)
+	}
+	for k, v := range topology {
+		features[k] = v
+	}
+
+	// Detect the cache sizes
+	caches, err := discoverCaches()
+	if err != nil {
+		logger.Printf("ERROR: Failed to detect CPU caches: %v", err)
+	}
+	for k, v := range caches {
+		features[k] = v
+	}
+
+	// Detect the SMT state
+	for k, v := range discoverSMT() {
+		features[k] = v
+	}
+
+	// Detect the enabled C-states
+	for k, v := range discoverCstates() {
+		features[k] = v
+	}
+
+	// Detect the CPU model
+	model, err := discoverModel()
+	if err != nil {
+		logger.Printf("ERROR: Failed to detect CPU model: %v", err)
+	}
+	for k, v := range model {
+		features[k] = v
+	}
+
+	// Detect the crypto extensions
+	crypto, err := discoverCrypto()
+	if err != nil {
+		logger.Printf("ERROR: Failed to detect CPU crypto extensions: %v", err)
+	}
+	for k, v := range crypto {
+		features[k] = v
+	}
+
+	// Check if Intel AMX is enabled
+	for k, v := range discoverAMX() {
+		features[k] = v
+	}
+
+	// Detect the Arm SVE vector length
+	for k, v := range discoverSVE() {
+		features[k] = v
+	}
+
+	// Check if Intel SST is enabled
+	for k, v := range discoverSST() {
+		features[k] = v
+	}
+
+	// Check if Intel SGX is enabled
+	for k, v := range discoverSGX() {
+		features[k] = v
+	}
+
+	// Check if AMD SEV is enabled
+	for k, v := range discoverSEV() {
+		features[k] = v
+	}
+
+	// Check if Intel TDX is enabled
+	for k, v := range discoverTDX() {
+		features[k] = v
+	}
+
 	return features, nil
 }
 
@@ -66,3 +148,13 @@ func haveThreadSiblings() (bool, error) {
 	// No siblings were found
 	return false, nil
 }
+
+// Check if a boolean parameter of a kvm module is enabled
+func kvmParamEnabled(module, param string) bool {
+	data, err := ioutil.ReadFile(path.Join("/sys/module", module, "parameters", param))
+	if err != nil {
+		return false
+	}
+	value := strings.TrimSpace(string(data))
+	return value == "Y" || value == "1"
+}
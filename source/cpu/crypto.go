@@ -0,0 +1,79 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpu
+
+import (
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/cpuinfoutils"
+)
+
+// CPU flags of crypto extensions, as reported in /proc/cpuinfo, mapped to
+// feature names
+var cryptoFlags = map[string]string{
+	// x86
+	"aes":        "aesni",
+	"sha_ni":     "shani",
+	"pclmulqdq":  "pclmulqdq",
+	"vaes":       "vaes",
+	"vpclmulqdq": "vpclmulqdq",
+	// Armv8 Cryptographic Extension
+	"pmull":  "pmull",
+	"sha1":   "sha1",
+	"sha2":   "sha2",
+	"sha3":   "sha3",
+	"sha512": "sha512",
+	"sm3":    "sm3",
+	"sm4":    "sm4",
+}
+
+// Flags of the base Armv8 Cryptographic Extension (CE)
+var armCeFlags = []string{"aes", "pmull", "sha1", "sha2"}
+
+// discoverCrypto detects the crypto extensions supported by the CPU
+func discoverCrypto() (source.Features, error) {
+	features := source.Features{}
+
+	cpu, err := cpuinfoutils.ReadFirstCPU()
+	if err != nil {
+		return nil, err
+	}
+	flags := cpu.Flags()
+	// x86 lists the flags as "flags", Arm as "Features"
+	_, arm := cpu["Features"]
+
+	for flag, name := range cryptoFlags {
+		if flags[flag] {
+			features["crypto."+name] = true
+		}
+	}
+	if arm {
+		// The AES flag of Arm is not AES-NI
+		delete(features, "crypto.aesni")
+		if flags["aes"] {
+			features["crypto.aes"] = true
+		}
+		ce := true
+		for _, flag := range armCeFlags {
+			ce = ce && flags[flag]
+		}
+		if ce {
+			features["crypto.ce"] = true
+		}
+	}
+
+	return features, nil
+}
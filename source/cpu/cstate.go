@@ -0,0 +1,90 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpu
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+// Default values of the max_cstate parameters of the idle drivers, i.e. no
+// restriction of idle states
+var defaultMaxCstate = map[string]int{
+	"intel_idle": 9,
+	"processor":  8,
+}
+
+// Detect the enabled CPU idle states (C-states)
+func discoverCstates() source.Features {
+	features := source.Features{}
+
+	data, err := ioutil.ReadFile(filepath.Join(sysfsCpuDir, "cpuidle/current_driver"))
+	if err != nil {
+		return features
+	}
+	driver := strings.TrimSpace(string(data))
+	if driver == "none" {
+		// No idle driver, e.g. on virtual machines or with idle=poll
+		features["cstate.enabled"] = false
+		return features
+	}
+	features["cstate.enabled"] = true
+	features["cstate.driver"] = driver
+
+	// The idle states of the first CPU, in increasing order of depth
+	dirs, _ := filepath.Glob(filepath.Join(sysfsCpuDir, "cpu0/cpuidle/state[0-9]*"))
+	sort.Slice(dirs, func(i, j int) bool {
+		return stateIndex(dirs[i]) < stateIndex(dirs[j])
+	})
+
+	limited := false
+	for _, dir := range dirs {
+		if disable, err := ioutil.ReadFile(filepath.Join(dir, "disable")); err == nil && strings.TrimSpace(string(disable)) != "0" {
+			limited = true
+			continue
+		}
+		if name, err := ioutil.ReadFile(filepath.Join(dir, "name")); err == nil {
+			features["cstate.deepest"] = strings.TrimSpace(string(name))
+		}
+	}
+
+	// Idle states may also be restricted with e.g. intel_idle.max_cstate on
+	// the kernel command line
+	for module, def := range defaultMaxCstate {
+		data, err := ioutil.ReadFile(filepath.Join("/sys/module", module, "parameters/max_cstate"))
+		if err != nil {
+			continue
+		}
+		if max, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && max < def {
+			limited = true
+		}
+	}
+	features["cstate.limited"] = limited
+
+	return features
+}
+
+// stateIndex returns the index of a cpuidle state directory, e.g. 2 for state2
+func stateIndex(dir string) int {
+	i, _ := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "state"))
+	return i
+}
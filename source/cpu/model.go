@@ -0,0 +1,103 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpu
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/cpuinfoutils"
+	"sigs.k8s.io/node-feature-discovery/source/internal/labelutils"
+)
+
+// Fields of /proc/cpuinfo identifying the CPU model, mapped to feature names
+var cpuinfoModelFields = map[string]string{
+	"vendor_id":  "vendor",
+	"cpu family": "family",
+	"model":      "model",
+	"stepping":   "stepping",
+	// POWER processor, e.g. "POWER9 (raw), altivec supported"
+	"cpu": "model",
+}
+
+// Main ID Register of Arm CPUs, exposed by the kernel on arm64
+const midrPath = "/sys/devices/system/cpu/cpu0/regs/identification/midr_el1"
+
+// Names of the implementer codes of Arm CPUs
+var armImplementers = map[uint64]string{
+	0x41: "ARM",
+	0x42: "Broadcom",
+	0x43: "Cavium",
+	0x46: "Fujitsu",
+	0x48: "HiSilicon",
+	0x4e: "NVIDIA",
+	0x50: "APM",
+	0x51: "Qualcomm",
+	0x61: "Apple",
+	0xc0: "Ampere",
+}
+
+// discoverModel detects the vendor, family, model and stepping of the CPU,
+// as reported by the kernel for the first CPU of the node. On Arm, the
+// implementer and part number are detected instead.
+func discoverModel() (source.Features, error) {
+	if data, err := ioutil.ReadFile(midrPath); err == nil {
+		return discoverArmModel(strings.TrimSpace(string(data)))
+	}
+
+	features := source.Features{}
+
+	cpu, err := cpuinfoutils.ReadFirstCPU()
+	if err != nil {
+		return nil, err
+	}
+	for key, name := range cpuinfoModelFields {
+		value := cpu[key]
+		if key == "cpu" && value != "" {
+			value = strings.TrimRight(strings.Fields(value)[0], ",")
+		}
+		// E.g. "IBM/S390" is not a valid label value
+		if value != "" && value != "unknown" {
+			features[name] = labelutils.Value(value)
+		}
+	}
+
+	return features, nil
+}
+
+// discoverArmModel decodes the Main ID Register of an Arm CPU
+func discoverArmModel(midr string) (source.Features, error) {
+	features := source.Features{}
+
+	v, err := strconv.ParseUint(strings.TrimPrefix(midr, "0x"), 16, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MIDR %q: %v", midr, err)
+	}
+
+	// Bits 31:24 hold the implementer and bits 15:4 the part number
+	implementer := (v >> 24) & 0xff
+	features["implementer"] = fmt.Sprintf("0x%02x", implementer)
+	features["part"] = fmt.Sprintf("0x%03x", (v>>4)&0xfff)
+	if name, ok := armImplementers[implementer]; ok {
+		features["vendor"] = name
+	}
+
+	return features, nil
+}
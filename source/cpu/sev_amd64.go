@@ -0,0 +1,70 @@
+// +build amd64
+
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpu
+
+import (
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/cpuidutils"
+)
+
+const (
+	// CPUID EAX input values
+	LEAF_EXT_BASIC_INFO             = 0x80000000
+	LEAF_EXT_MEMORY_ENCRYPTION_INFO = 0x8000001f
+
+	// CPUID bitmasks
+	MEMORY_ENCRYPTION_INFO_EAX_SEV     = 1 << 1
+	MEMORY_ENCRYPTION_INFO_EAX_SEV_ES  = 1 << 3
+	MEMORY_ENCRYPTION_INFO_EAX_SEV_SNP = 1 << 4
+)
+
+// Detect AMD Secure Encrypted Virtualization (SEV) and its extensions that
+// are supported by the CPU and enabled in KVM
+func discoverSEV() source.Features {
+	features := source.Features{}
+
+	if cpuidutils.Cpuid(LEAF_EXT_BASIC_INFO, 0).EAX < LEAF_EXT_MEMORY_ENCRYPTION_INFO {
+		return features
+	}
+
+	memEnc := cpuidutils.Cpuid(LEAF_EXT_MEMORY_ENCRYPTION_INFO, 0)
+	if memEnc.EAX&MEMORY_ENCRYPTION_INFO_EAX_SEV == 0 || !kvmParamEnabled("kvm_amd", "sev") {
+		return features
+	}
+
+	// ECX is the number of ASIDs available for encrypted guests, and EDX
+	// the first ASID usable by SEV guests. ASIDs below that are reserved for
+	// SEV-ES (and SEV-SNP) guests.
+	features["sev.enabled"] = true
+	if memEnc.ECX >= memEnc.EDX && memEnc.EDX > 0 {
+		features["sev.asids"] = memEnc.ECX - memEnc.EDX + 1
+	}
+
+	if memEnc.EAX&MEMORY_ENCRYPTION_INFO_EAX_SEV_ES != 0 && kvmParamEnabled("kvm_amd", "sev_es") {
+		features["sev.es.enabled"] = true
+		if memEnc.EDX > 1 {
+			features["sev.es.asids"] = memEnc.EDX - 1
+		}
+		if memEnc.EAX&MEMORY_ENCRYPTION_INFO_EAX_SEV_SNP != 0 && kvmParamEnabled("kvm_amd", "sev_snp") {
+			features["sev.snp.enabled"] = true
+		}
+	}
+
+	return features
+}
@@ -0,0 +1,124 @@
+// +build amd64
+
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpu
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/cpuidutils"
+)
+
+const (
+	// CPUID EAX input values
+	LEAF_BASIC_INFO        = 0x00
+	LEAF_EXT_FEATURE_FLAGS = 0x07
+	LEAF_SGX               = 0x12
+
+	// CPUID ECX input values
+	SGX_SUBLEAF_CAPABILITIES = 0
+	SGX_SUBLEAF_EPC_FIRST    = 2
+
+	// CPUID bitmasks
+	EXT_FEATURE_FLAGS_EBX_SGX    = 1 << 2
+	EXT_FEATURE_FLAGS_ECX_SGX_LC = 1 << 30
+	SGX_CAPABILITIES_EAX_SGX1    = 1 << 0
+	SGX_EPC_EAX_TYPE_MASK        = 0xf
+	SGX_EPC_EAX_TYPE_SECTION     = 0x1
+	SGX_EPC_LOW_MASK             = 0xfffff000
+	SGX_EPC_HIGH_MASK            = 0x000fffff
+)
+
+// Detect Intel SGX and the size of the Enclave Page Cache (EPC)
+func discoverSGX() source.Features {
+	features := source.Features{}
+
+	if cpuidutils.Cpuid(LEAF_BASIC_INFO, 0).EAX < LEAF_SGX {
+		return features
+	}
+
+	extFeatures := cpuidutils.Cpuid(LEAF_EXT_FEATURE_FLAGS, 0)
+	if extFeatures.EBX&EXT_FEATURE_FLAGS_EBX_SGX == 0 {
+		return features
+	}
+	// SGX is reported by cpuid but it is usable only if SGX1 instructions are
+	// available, i.e. SGX has been enabled in the BIOS
+	if cpuidutils.Cpuid(LEAF_SGX, SGX_SUBLEAF_CAPABILITIES).EAX&SGX_CAPABILITIES_EAX_SGX1 == 0 {
+		return features
+	}
+
+	epcSize, fallback := sgxEpcSize()
+	if epcSize == 0 {
+		return features
+	}
+
+	features["sgx.enabled"] = true
+	features["sgx.epc"] = epcSize
+	if fallback {
+		features["sgx.epc.source"] = "cpuid"
+	}
+	// Flexible Launch Control, required by the upstream kernel driver
+	if extFeatures.ECX&EXT_FEATURE_FLAGS_ECX_SGX_LC != 0 {
+		features["sgx.lc"] = true
+	}
+
+	return features
+}
+
+// The kernel version doesn't change while NFD runs
+var sgxEpcFallbackLog sync.Once
+
+// Get the total EPC size in bytes. Prefer the size reported by the kernel,
+// falling back to enumerating the EPC sections with cpuid, in which case
+// fallback is true.
+func sgxEpcSize() (size uint64, fallback bool) {
+	files, _ := filepath.Glob("/sys/devices/system/node/node*/x86/sgx_total_bytes")
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			logger.Printf("ERROR: failed to read %s: %s", file, err)
+			continue
+		}
+		n, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			logger.Printf("ERROR: failed to parse %s: %s", file, err)
+			continue
+		}
+		size += n
+	}
+	if size > 0 {
+		return size, false
+	}
+	sgxEpcFallbackLog.Do(func() {
+		logger.Printf("SGX EPC size not available in sysfs, enumerating EPC sections with cpuid")
+	})
+
+	for i := uint32(SGX_SUBLEAF_EPC_FIRST); ; i++ {
+		epc := cpuidutils.Cpuid(LEAF_SGX, i)
+		if epc.EAX&SGX_EPC_EAX_TYPE_MASK != SGX_EPC_EAX_TYPE_SECTION {
+			break
+		}
+		size += uint64(epc.ECX&SGX_EPC_LOW_MASK) + uint64(epc.EDX&SGX_EPC_HIGH_MASK)<<32
+	}
+	return size, true
+}
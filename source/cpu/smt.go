@@ -0,0 +1,59 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpu
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/topologyutils"
+)
+
+const sysfsCpuDir = "/sys/devices/system/cpu"
+
+// Detect the state of simultaneous multithreading (SMT), and the number of
+// hardware threads per core
+func discoverSMT() source.Features {
+	features := source.Features{}
+
+	// The SMT control interface is not available on all architectures
+	if data, err := ioutil.ReadFile(filepath.Join(sysfsCpuDir, "smt/active")); err == nil {
+		features["smt.enabled"] = strings.TrimSpace(string(data)) == "1"
+	}
+	if data, err := ioutil.ReadFile(filepath.Join(sysfsCpuDir, "smt/control")); err == nil {
+		features["smt.control"] = strings.TrimSpace(string(data))
+	}
+
+	threads, err := topologyutils.ThreadsPerCore()
+	if err != nil {
+		logger.Printf("failed to detect the number of threads per core: %s", err)
+	} else if threads > 0 {
+		features["smt.threads_per_core"] = threads
+		if _, ok := features["smt.enabled"]; !ok {
+			features["smt.enabled"] = threads > 1
+		}
+	}
+
+	// Core scheduling prevents untrusted tasks from sharing a core
+	if coreSchedulingSupported() {
+		features["smt.core_scheduling"] = true
+	}
+
+	return features
+}
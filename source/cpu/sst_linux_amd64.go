@@ -0,0 +1,146 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpu
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+const (
+	isstDevPath = "/dev/isst_interface"
+
+	// ioctl of the intel_speed_select interface, from linux/isst_if.h
+	ISST_IF_MBOX_COMMAND = 0xc008fe03
+
+	// Mailbox commands
+	CONFIG_TDP                 = 0x7f
+	CONFIG_TDP_GET_LEVELS_INFO = 0x00
+	CONFIG_TDP_GET_TDP_CONTROL = 0x01
+	READ_PM_CONFIG             = 0x94
+	PM_FEATURE                 = 0x03
+
+	// Response bitmasks
+	LEVELS_INFO_ENABLED       = 1 << 31
+	LEVELS_INFO_CURRENT_MASK  = 0xff
+	LEVELS_INFO_CURRENT_SHIFT = 16
+	TDP_CONTROL_PBF_ENABLED   = 1 << 17
+	PM_FEATURE_CP_ENABLED     = 1 << 16
+)
+
+// struct isst_if_mbox_cmd
+type isstMboxCmd struct {
+	logicalCpu uint32
+	parameter  uint32
+	reqData    uint32
+	respData   uint32
+	command    uint16
+	subCommand uint16
+	reserved   uint32
+}
+
+// struct isst_if_mbox_cmds with a single command
+type isstMboxCmds struct {
+	cmdCount uint32
+	mboxCmd  [1]isstMboxCmd
+}
+
+// Detect Intel Speed Select Technology (SST) features enabled on the first
+// package: performance profiles (SST-PP), base frequency (SST-BF) and core
+// power (SST-CP)
+func discoverSST() source.Features {
+	features := source.Features{}
+
+	// SST-BF is detected from the base frequencies of the CPUs, as reported
+	// by intel_pstate. The mailbox interface, requiring privileges, is only
+	// used if the kernel does not report base frequencies.
+	bfEnabled, bfKnown := sstBfFromBaseFrequency()
+	if bfEnabled {
+		features["sst.bf.enabled"] = true
+	}
+
+	f, err := os.OpenFile(isstDevPath, os.O_RDWR, 0)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Printf("failed to open %s: %s", isstDevPath, err)
+		}
+		return features
+	}
+	defer f.Close()
+
+	levels, err := isstMbox(f, CONFIG_TDP, CONFIG_TDP_GET_LEVELS_INFO, 0)
+	if err != nil {
+		logger.Printf("failed to read SST-PP levels: %s", err)
+		return features
+	}
+	level := (levels >> LEVELS_INFO_CURRENT_SHIFT) & LEVELS_INFO_CURRENT_MASK
+	if levels&LEVELS_INFO_ENABLED != 0 {
+		features["sst.pp.enabled"] = true
+		features["sst.pp.level"] = level
+	}
+
+	if !bfKnown {
+		if control, err := isstMbox(f, CONFIG_TDP, CONFIG_TDP_GET_TDP_CONTROL, level); err == nil && control&TDP_CONTROL_PBF_ENABLED != 0 {
+			features["sst.bf.enabled"] = true
+		}
+	}
+
+	if pm, err := isstMbox(f, READ_PM_CONFIG, PM_FEATURE, 0); err == nil && pm&PM_FEATURE_CP_ENABLED != 0 {
+		features["sst.cp.enabled"] = true
+	}
+
+	return features
+}
+
+// Send a mailbox command for the first CPU to the SST interface
+func isstMbox(f *os.File, command, subCommand uint16, parameter uint32) (uint32, error) {
+	cmds := isstMboxCmds{cmdCount: 1}
+	cmds.mboxCmd[0] = isstMboxCmd{command: command, subCommand: subCommand, parameter: parameter}
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), ISST_IF_MBOX_COMMAND, uintptr(unsafe.Pointer(&cmds)))
+	if errno != 0 {
+		return 0, errno
+	}
+	return cmds.mboxCmd[0].respData, nil
+}
+
+// With SST-BF enabled, the high priority cores have a higher base frequency
+// than the rest of the cores. The per-CPU base_frequency attribute is only
+// provided by intel_pstate in HWP mode, known is false if it is not
+// available.
+func sstBfFromBaseFrequency() (enabled, known bool) {
+	files, err := filepath.Glob(filepath.Join(sysfsCpuDir, "cpu[0-9]*/cpufreq/base_frequency"))
+	if err != nil || len(files) == 0 {
+		return false, false
+	}
+
+	freqs := map[string]bool{}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return false, false
+		}
+		freqs[strings.TrimSpace(string(data))] = true
+	}
+	return len(freqs) > 1, true
+}
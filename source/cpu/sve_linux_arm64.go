@@ -0,0 +1,62 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpu
+
+import (
+	"runtime"
+	"syscall"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+const (
+	// prctl options, from linux/prctl.h
+	PR_SVE_SET_VL      = 50
+	PR_SVE_VL_LEN_MASK = 0xffff
+
+	// Largest vector length of the architecture in bytes, from
+	// asm/sigcontext.h
+	SVE_VL_MAX = 8192
+)
+
+// Detect the maximum vector length of the Arm Scalable Vector Extension
+// (SVE) supported by the CPU and kernel, in bits
+func discoverSVE() source.Features {
+	features := source.Features{}
+
+	// Requesting the architectural maximum sets the vector length of the
+	// thread to the largest one supported. The thread is locked, and thus
+	// terminated with the goroutine, so that the vector length of other
+	// goroutines is not affected.
+	result := make(chan uintptr)
+	go func() {
+		runtime.LockOSThread()
+		ret, _, errno := syscall.Syscall(syscall.SYS_PRCTL, PR_SVE_SET_VL, SVE_VL_MAX, 0)
+		if errno != 0 {
+			// SVE is not supported by the CPU or kernel
+			ret = 0
+		}
+		result <- ret
+	}()
+
+	// The vector length is returned in bytes
+	if vl := <-result & PR_SVE_VL_LEN_MASK; vl > 0 {
+		features["sve.vl"] = vl * 8
+	}
+
+	return features
+}
@@ -0,0 +1,79 @@
+// +build amd64
+
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpu
+
+import (
+	"encoding/binary"
+	"os"
+	"sync"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+const (
+	// Model specific registers
+	MSR_IA32_MKTME_KEYID_PARTITIONING = 0x87
+
+	msrDevPath = "/dev/cpu/0/msr"
+)
+
+// Reading the MSR fails on every pass if it isn't accessible, so the failure
+// is logged once only
+var tdxKeysWarning sync.Once
+
+// Detect Intel Trust Domain Extensions (TDX) and the number of private
+// key IDs available for trust domains
+func discoverTDX() source.Features {
+	features := source.Features{}
+
+	// The kernel only enables TDX in KVM if it was successfully initialized
+	if !kvmParamEnabled("kvm_intel", "tdx") {
+		return features
+	}
+	features["tdx.enabled"] = true
+
+	// Bits 63:32 of the key ID partitioning MSR hold the number of TDX
+	// private key IDs. Reading MSRs requires the msr module and privileges,
+	// so this is best-effort only.
+	msr, err := readMsr(MSR_IA32_MKTME_KEYID_PARTITIONING)
+	if err != nil {
+		tdxKeysWarning.Do(func() {
+			logger.Printf("WARNING: failed to read the number of TDX keys: %s", err)
+		})
+	} else if keys := msr >> 32; keys > 0 {
+		features["tdx.total_keys"] = keys
+	}
+
+	return features
+}
+
+// Read a model specific register of the first CPU
+func readMsr(msr int64) (uint64, error) {
+	f, err := os.Open(msrDevPath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 8)
+	if _, err := f.ReadAt(buf, msr); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(buf), nil
+}
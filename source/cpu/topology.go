@@ -0,0 +1,61 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpu
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+// Count the sockets, cores and hardware threads of the online CPUs
+func discoverTopology() (source.Features, error) {
+	features := source.Features{}
+
+	// Offline CPUs have no topology information
+	dirs, err := filepath.Glob(filepath.Join(sysfsCpuDir, "cpu[0-9]*/topology"))
+	if err != nil {
+		return nil, err
+	}
+
+	sockets := map[string]bool{}
+	cores := map[string]bool{}
+	for _, dir := range dirs {
+		pkg, err := ioutil.ReadFile(filepath.Join(dir, "physical_package_id"))
+		if err != nil {
+			return nil, err
+		}
+		core, err := ioutil.ReadFile(filepath.Join(dir, "core_id"))
+		if err != nil {
+			return nil, err
+		}
+		socket := strings.TrimSpace(string(pkg))
+		sockets[socket] = true
+		// Core IDs are only unique within a socket
+		cores[socket+"/"+strings.TrimSpace(string(core))] = true
+	}
+
+	if len(dirs) > 0 {
+		features["sockets"] = len(sockets)
+		features["cores"] = len(cores)
+		features["threads"] = len(dirs)
+	}
+
+	return features, nil
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpuid
+
+import (
+	"sigs.k8s.io/node-feature-discovery/source/internal/cpuidutils"
+)
+
+const (
+	// CPUID EAX input values
+	LEAF_BASIC_INFO = 0x00
+
+	// CPUID bitmasks
+	EXT_FEATURE_FLAGS_EDX_AMXBF16 = 1 << 22
+	EXT_FEATURE_FLAGS_EDX_AMXTILE = 1 << 24
+	EXT_FEATURE_FLAGS_EDX_AMXINT8 = 1 << 25
+)
+
+// Get the AMX features supported by the CPU. Whether the OS has enabled AMX
+// is detected by the cpu source.
+func getAmxFeatures() []string {
+	features := []string{}
+
+	// EAX of the basic info leaf holds the maximum leaf
+	if cpuidutils.Cpuid(LEAF_BASIC_INFO, 0).EAX < LEAF_EXT_FEATURE_FLAGS {
+		return features
+	}
+
+	extFeatures := cpuidutils.Cpuid(LEAF_EXT_FEATURE_FLAGS, EXT_FEATURE_FLAGS_SUBLEAF_0)
+	flags := []cpuidFlag{
+		{extFeatures.EDX, EXT_FEATURE_FLAGS_EDX_AMXBF16, "AMXBF16"},
+		{extFeatures.EDX, EXT_FEATURE_FLAGS_EDX_AMXTILE, "AMXTILE"},
+		{extFeatures.EDX, EXT_FEATURE_FLAGS_EDX_AMXINT8, "AMXINT8"},
+	}
+	for _, f := range flags {
+		if f.reg&f.mask != 0 {
+			features = append(features, f.name)
+		}
+	}
+	return features
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpuid
+
+import (
+	"sigs.k8s.io/node-feature-discovery/source/internal/cpuidutils"
+)
+
+const (
+	// CPUID EAX input values
+	LEAF_EXT_FEATURE_FLAGS = 0x07
+
+	// CPUID ECX input values
+	EXT_FEATURE_FLAGS_SUBLEAF_0 = 0
+	EXT_FEATURE_FLAGS_SUBLEAF_1 = 1
+
+	// CPUID bitmasks
+	EXT_FEATURE_FLAGS_ECX_AVX512VBMI2        = 1 << 6
+	EXT_FEATURE_FLAGS_ECX_AVX512VNNI         = 1 << 11
+	EXT_FEATURE_FLAGS_ECX_AVX512BITALG       = 1 << 12
+	EXT_FEATURE_FLAGS_ECX_AVX512VPOPCNTDQ    = 1 << 14
+	EXT_FEATURE_FLAGS_EDX_AVX512VP2INTERSECT = 1 << 8
+	EXT_FEATURE_FLAGS_EDX_AVX512FP16         = 1 << 23
+	EXT_FEATURE_FLAGS_1_EAX_AVX512BF16       = 1 << 5
+)
+
+// cpuidFlag is a feature flag in a register returned by cpuid
+type cpuidFlag struct {
+	reg  uint32
+	mask uint32
+	name string
+}
+
+// Get the AVX-512 sub-features not reported by the cpuid library. Must only
+// be used if AVX512F is supported, i.e. the OS saves the AVX-512 state.
+func getAvx512Features() []string {
+	features := []string{}
+
+	extFeatures := cpuidutils.Cpuid(LEAF_EXT_FEATURE_FLAGS, EXT_FEATURE_FLAGS_SUBLEAF_0)
+	flags := []cpuidFlag{
+		{extFeatures.ECX, EXT_FEATURE_FLAGS_ECX_AVX512VBMI2, "AVX512VBMI2"},
+		{extFeatures.ECX, EXT_FEATURE_FLAGS_ECX_AVX512VNNI, "AVX512VNNI"},
+		{extFeatures.ECX, EXT_FEATURE_FLAGS_ECX_AVX512BITALG, "AVX512BITALG"},
+		{extFeatures.ECX, EXT_FEATURE_FLAGS_ECX_AVX512VPOPCNTDQ, "AVX512VPOPCNTDQ"},
+		{extFeatures.EDX, EXT_FEATURE_FLAGS_EDX_AVX512VP2INTERSECT, "AVX512VP2INTERSECT"},
+		{extFeatures.EDX, EXT_FEATURE_FLAGS_EDX_AVX512FP16, "AVX512FP16"},
+	}
+	// EAX of sub-leaf 0 holds the maximum sub-leaf
+	if extFeatures.EAX >= EXT_FEATURE_FLAGS_SUBLEAF_1 {
+		extFeatures1 := cpuidutils.Cpuid(LEAF_EXT_FEATURE_FLAGS, EXT_FEATURE_FLAGS_SUBLEAF_1)
+		flags = append(flags, cpuidFlag{extFeatures1.EAX, EXT_FEATURE_FLAGS_1_EAX_AVX512BF16, "AVX512BF16"})
+	}
+
+	for _, f := range flags {
+		if f.reg&f.mask != 0 {
+			features = append(features, f.name)
+		}
+	}
+	return features
+}
@@ -16,6 +16,22 @@ limitations under the License.
 
 package cpuid
 
+import (
+	"log"
+	"os"
+)
+
+// Configuration file options
+type NFDConfig struct {
+	// Read CPU features from /proc/cpuinfo instead of using the cpuid
+	// instruction
+	UseCpuinfo bool `json:"useCpuinfo,omitempty"`
+}
+
+var Config = NFDConfig{}
+
+var logger = log.New(os.Stderr, "", log.LstdFlags)
+
 // Source implements FeatureSource.
 type Source struct{}
 
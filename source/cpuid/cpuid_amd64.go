@@ -17,15 +17,44 @@ limitations under the License.
 package cpuid
 
 import (
+	"fmt"
+	"sync"
+
 	"github.com/klauspost/cpuid"
 	"sigs.k8s.io/node-feature-discovery/source"
 )
 
+// Logged on the first pass only, the cpuid instruction stays unusable
+var cpuinfoFallbackLog sync.Once
+
 // Discover returns feature names for all the supported CPU features.
 func (s Source) Discover() (source.Features, error) {
-	// Get the cpu features as strings
 	features := source.Features{}
-	for _, f := range cpuid.CPU.Features.Strings() {
+
+	// Get the cpu features as strings. The features of the cpuid library
+	// are detected once at its initialization, but no further cpuid
+	// instructions are executed if /proc/cpuinfo is to be used.
+	var flags []string
+	if !Config.UseCpuinfo {
+		flags = cpuid.CPU.Features.Strings()
+		if cpuid.CPU.AVX512F() {
+			flags = append(flags, getAvx512Features()...)
+		}
+		flags = append(flags, getAmxFeatures()...)
+	}
+	if len(flags) == 0 {
+		var err error
+		cpuinfoFallbackLog.Do(func() {
+			logger.Printf("detecting CPU features from /proc/cpuinfo")
+		})
+		flags, err = getFeaturesFromCpuinfo()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CPU features from /proc/cpuinfo: %s", err)
+		}
+		features["source"] = "cpuinfo"
+	}
+
+	for _, f := range flags {
 		features[f] = true
 	}
 	return features, nil
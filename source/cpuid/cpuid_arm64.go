@@ -23,6 +23,10 @@ package cpuid
 unsigned long gethwcap() {
 	return getauxval(AT_HWCAP);
 }
+
+unsigned long gethwcap2() {
+	return getauxval(AT_HWCAP2);
+}
 */
 import "C"
 
@@ -56,6 +60,38 @@ const (
 	CPU_ARM64_FEATURE_ASIMDDP
 	CPU_ARM64_FEATURE_SHA512
 	CPU_ARM64_FEATURE_SVE
+	CPU_ARM64_FEATURE_ASIMDFHM
+	CPU_ARM64_FEATURE_DIT
+	CPU_ARM64_FEATURE_USCAT
+	CPU_ARM64_FEATURE_ILRCPC
+	CPU_ARM64_FEATURE_FLAGM
+	CPU_ARM64_FEATURE_SSBS
+	CPU_ARM64_FEATURE_SB
+	CPU_ARM64_FEATURE_PACA
+	CPU_ARM64_FEATURE_PACG
+)
+
+/* features reported in AT_HWCAP2 */
+const (
+	CPU_ARM64_FEATURE2_DCPODP = 1 << iota
+	CPU_ARM64_FEATURE2_SVE2
+	CPU_ARM64_FEATURE2_SVEAES
+	CPU_ARM64_FEATURE2_SVEPMULL
+	CPU_ARM64_FEATURE2_SVEBITPERM
+	CPU_ARM64_FEATURE2_SVESHA3
+	CPU_ARM64_FEATURE2_SVESM4
+	CPU_ARM64_FEATURE2_FLAGM2
+	CPU_ARM64_FEATURE2_FRINT
+	CPU_ARM64_FEATURE2_SVEI8MM
+	CPU_ARM64_FEATURE2_SVEF32MM
+	CPU_ARM64_FEATURE2_SVEF64MM
+	CPU_ARM64_FEATURE2_SVEBF16
+	CPU_ARM64_FEATURE2_I8MM
+	CPU_ARM64_FEATURE2_BF16
+	CPU_ARM64_FEATURE2_DGH
+	CPU_ARM64_FEATURE2_RNG
+	CPU_ARM64_FEATURE2_BTI
+	CPU_ARM64_FEATURE2_MTE
 )
 
 var flagNames_arm64 = map[uint64]string{
@@ -82,15 +118,49 @@ var flagNames_arm64 = map[uint64]string{
 	CPU_ARM64_FEATURE_ASIMDDP:  "ASIMDDP",
 	CPU_ARM64_FEATURE_SHA512:   "SHA512",
 	CPU_ARM64_FEATURE_SVE:      "SVE",
+	CPU_ARM64_FEATURE_ASIMDFHM: "ASIMDFHM",
+	CPU_ARM64_FEATURE_DIT:      "DIT",
+	CPU_ARM64_FEATURE_USCAT:    "USCAT",
+	CPU_ARM64_FEATURE_ILRCPC:   "ILRCPC",
+	CPU_ARM64_FEATURE_FLAGM:    "FLAGM",
+	CPU_ARM64_FEATURE_SSBS:     "SSBS",
+	CPU_ARM64_FEATURE_SB:       "SB",
+	CPU_ARM64_FEATURE_PACA:     "PACA",
+	CPU_ARM64_FEATURE_PACG:     "PACG",
+}
+
+var flagNames2_arm64 = map[uint64]string{
+	CPU_ARM64_FEATURE2_DCPODP:     "DCPODP",
+	CPU_ARM64_FEATURE2_SVE2:       "SVE2",
+	CPU_ARM64_FEATURE2_SVEAES:     "SVEAES",
+	CPU_ARM64_FEATURE2_SVEPMULL:   "SVEPMULL",
+	CPU_ARM64_FEATURE2_SVEBITPERM: "SVEBITPERM",
+	CPU_ARM64_FEATURE2_SVESHA3:    "SVESHA3",
+	CPU_ARM64_FEATURE2_SVESM4:     "SVESM4",
+	CPU_ARM64_FEATURE2_FLAGM2:     "FLAGM2",
+	CPU_ARM64_FEATURE2_FRINT:      "FRINT",
+	CPU_ARM64_FEATURE2_SVEI8MM:    "SVEI8MM",
+	CPU_ARM64_FEATURE2_SVEF32MM:   "SVEF32MM",
+	CPU_ARM64_FEATURE2_SVEF64MM:   "SVEF64MM",
+	CPU_ARM64_FEATURE2_SVEBF16:    "SVEBF16",
+	CPU_ARM64_FEATURE2_I8MM:       "I8MM",
+	CPU_ARM64_FEATURE2_BF16:       "BF16",
+	CPU_ARM64_FEATURE2_DGH:        "DGH",
+	CPU_ARM64_FEATURE2_RNG:        "RNG",
+	CPU_ARM64_FEATURE2_BTI:        "BTI",
+	CPU_ARM64_FEATURE2_MTE:        "MTE",
 }
 
 func getFeaturesFromHWCAP() []string {
 	r := make([]string, 0, 20)
 	hwcap := uint64(C.gethwcap())
+	hwcap2 := uint64(C.gethwcap2())
 	for i := uint(0); i < 64; i++ {
 		key := uint64(1 << i)
-		val := flagNames_arm64[key]
-		if hwcap&key != 0 {
+		if val, ok := flagNames_arm64[key]; ok && hwcap&key != 0 {
+			r = append(r, val)
+		}
+		if val, ok := flagNames2_arm64[key]; ok && hwcap2&key != 0 {
 			r = append(r, val)
 		}
 	}
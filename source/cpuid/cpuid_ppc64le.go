@@ -0,0 +1,147 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpuid
+
+/*
+#include <sys/auxv.h>
+
+unsigned long gethwcap() {
+	return getauxval(AT_HWCAP);
+}
+
+unsigned long gethwcap2() {
+	return getauxval(AT_HWCAP2);
+}
+*/
+import "C"
+
+import (
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/topologyutils"
+)
+
+/* features reported in AT_HWCAP, from asm/cputable.h */
+const (
+	CPU_PPC64_FEATURE_TRUE_LE     = 0x00000002
+	CPU_PPC64_FEATURE_HAS_VSX     = 0x00000080
+	CPU_PPC64_FEATURE_ARCH_2_06   = 0x00000100
+	CPU_PPC64_FEATURE_HAS_DFP     = 0x00000400
+	CPU_PPC64_FEATURE_ARCH_2_05   = 0x00001000
+	CPU_PPC64_FEATURE_SMT         = 0x00004000
+	CPU_PPC64_FEATURE_HAS_ALTIVEC = 0x10000000
+)
+
+/* features reported in AT_HWCAP2 */
+const (
+	CPU_PPC64_FEATURE2_MMA         = 0x00020000
+	CPU_PPC64_FEATURE2_ARCH_3_1    = 0x00040000
+	CPU_PPC64_FEATURE2_SCV         = 0x00100000
+	CPU_PPC64_FEATURE2_DARN        = 0x00200000
+	CPU_PPC64_FEATURE2_HAS_IEEE128 = 0x00400000
+	CPU_PPC64_FEATURE2_ARCH_3_00   = 0x00800000
+	CPU_PPC64_FEATURE2_VEC_CRYPTO  = 0x02000000
+	CPU_PPC64_FEATURE2_EBB         = 0x10000000
+	CPU_PPC64_FEATURE2_HTM         = 0x40000000
+	CPU_PPC64_FEATURE2_ARCH_2_07   = 0x80000000
+)
+
+var flagNames_ppc64le = map[uint64]string{
+	CPU_PPC64_FEATURE_TRUE_LE:     "TRUE_LE",
+	CPU_PPC64_FEATURE_HAS_VSX:     "VSX",
+	CPU_PPC64_FEATURE_ARCH_2_06:   "ARCH_2_06",
+	CPU_PPC64_FEATURE_HAS_DFP:     "DFP",
+	CPU_PPC64_FEATURE_ARCH_2_05:   "ARCH_2_05",
+	CPU_PPC64_FEATURE_SMT:         "SMT",
+	CPU_PPC64_FEATURE_HAS_ALTIVEC: "ALTIVEC",
+}
+
+var flagNames2_ppc64le = map[uint64]string{
+	CPU_PPC64_FEATURE2_MMA:         "MMA",
+	CPU_PPC64_FEATURE2_ARCH_3_1:    "ARCH_3_1",
+	CPU_PPC64_FEATURE2_SCV:         "SCV",
+	CPU_PPC64_FEATURE2_DARN:        "DARN",
+	CPU_PPC64_FEATURE2_HAS_IEEE128: "IEEE128",
+	CPU_PPC64_FEATURE2_ARCH_3_00:   "ARCH_3_00",
+	CPU_PPC64_FEATURE2_VEC_CRYPTO:  "VEC_CRYPTO",
+	CPU_PPC64_FEATURE2_EBB:         "EBB",
+	CPU_PPC64_FEATURE2_HTM:         "HTM",
+	CPU_PPC64_FEATURE2_ARCH_2_07:   "ARCH_2_07",
+}
+
+// Power ISA versions, from the latest, by the HWCAP flag reporting them
+var isaLevels_ppc64le = []struct {
+	hwcap2  bool
+	feature uint64
+	level   string
+}{
+	{true, CPU_PPC64_FEATURE2_ARCH_3_1, "3.1"},
+	{true, CPU_PPC64_FEATURE2_ARCH_3_00, "3.0"},
+	{true, CPU_PPC64_FEATURE2_ARCH_2_07, "2.07"},
+	{false, CPU_PPC64_FEATURE_ARCH_2_06, "2.06"},
+	{false, CPU_PPC64_FEATURE_ARCH_2_05, "2.05"},
+}
+
+// Get the latest Power ISA version supported by the CPU
+func getIsaLevel() string {
+	hwcap := uint64(C.gethwcap())
+	hwcap2 := uint64(C.gethwcap2())
+	for _, l := range isaLevels_ppc64le {
+		if (l.hwcap2 && hwcap2&l.feature != 0) || (!l.hwcap2 && hwcap&l.feature != 0) {
+			return l.level
+		}
+	}
+	return ""
+}
+
+func getFeaturesFromHWCAP() []string {
+	r := make([]string, 0, 20)
+	hwcap := uint64(C.gethwcap())
+	hwcap2 := uint64(C.gethwcap2())
+	for i := uint(0); i < 64; i++ {
+		key := uint64(1 << i)
+		if val, ok := flagNames_ppc64le[key]; ok && hwcap&key != 0 {
+			r = append(r, val)
+		}
+		if val, ok := flagNames2_ppc64le[key]; ok && hwcap2&key != 0 {
+			r = append(r, val)
+		}
+	}
+	return r
+}
+
+// Discover returns feature names for all the supported CPU features.
+func (s Source) Discover() (source.Features, error) {
+	// Get the cpu features as strings
+	features := source.Features{}
+
+	for _, f := range getFeaturesFromHWCAP() {
+		features[f] = true
+	}
+
+	if level := getIsaLevel(); level != "" {
+		features["ISA_LEVEL"] = level
+	}
+	// The SMT mode may be changed at runtime, e.g. with ppc64_cpu --smt
+	threads, err := topologyutils.ThreadsPerCore()
+	if err != nil {
+		logger.Printf("ERROR: failed to detect the SMT mode: %s", err)
+	} else if threads > 0 {
+		features["SMT_MODE"] = threads
+	}
+
+	return features, nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpuid
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/cpuinfoutils"
+)
+
+// Mapping of s390x /proc/cpuinfo features (hwcaps) to feature names
+var featureNames_s390x = map[string]string{
+	"dflt":  "DFLT",
+	"dfp":   "DFP",
+	"gs":    "GS",
+	"msa":   "CPACF",
+	"nnpa":  "NNPA",
+	"sie":   "SIE",
+	"sort":  "SORT",
+	"te":    "TE",
+	"vx":    "VX",
+	"vxd":   "VXD",
+	"vxe":   "VXE",
+	"vxe2":  "VXE2",
+	"vxp":   "VXP",
+	"vxp2":  "VXP2",
+	"zarch": "ZARCH",
+}
+
+// Mapping of s390x facility numbers to feature names, for facilities not
+// reported as hwcaps
+var facilityNames_s390x = map[string]string{
+	"76":  "MSA3",
+	"77":  "MSA4",
+	"57":  "MSA5",
+	"146": "MSA8",
+	"155": "MSA9",
+}
+
+// Discover returns feature names for all the supported CPU features.
+func (s Source) Discover() (source.Features, error) {
+	// The features and facilities are listed once for all CPUs
+	cpu, err := cpuinfoutils.ReadFirstCPU()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CPU features from /proc/cpuinfo: %s", err)
+	}
+
+	features := source.Features{}
+	for _, flag := range strings.Fields(cpu["features"]) {
+		if name, ok := featureNames_s390x[flag]; ok {
+			features[name] = true
+		}
+	}
+	for _, flag := range strings.Fields(cpu["facilities"]) {
+		if name, ok := facilityNames_s390x[flag]; ok {
+			features[name] = true
+		}
+	}
+
+	return features, nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpuid
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/node-feature-discovery/source/internal/cpuinfoutils"
+)
+
+// Mapping of x86 /proc/cpuinfo flags to the feature names reported by cpuid
+var cpuinfoFlags = map[string]string{
+	"3dnow":               "AMD3DNOW",
+	"3dnowext":            "AMD3DNOWEXT",
+	"abm":                 "LZCNT",
+	"adx":                 "ADX",
+	"aes":                 "AESNI",
+	"amx_bf16":            "AMXBF16",
+	"amx_int8":            "AMXINT8",
+	"amx_tile":            "AMXTILE",
+	"avx":                 "AVX",
+	"avx2":                "AVX2",
+	"avx512_bf16":         "AVX512BF16",
+	"avx512_bitalg":       "AVX512BITALG",
+	"avx512_fp16":         "AVX512FP16",
+	"avx512_vbmi2":        "AVX512VBMI2",
+	"avx512_vnni":         "AVX512VNNI",
+	"avx512_vp2intersect": "AVX512VP2INTERSECT",
+	"avx512_vpopcntdq":    "AVX512VPOPCNTDQ",
+	"avx512bw":            "AVX512BW",
+	"avx512cd":            "AVX512CD",
+	"avx512dq":            "AVX512DQ",
+	"avx512er":            "AVX512ER",
+	"avx512f":             "AVX512F",
+	"avx512ifma":          "AVX512IFMA",
+	"avx512pf":            "AVX512PF",
+	"avx512vbmi":          "AVX512VBMI",
+	"avx512vl":            "AVX512VL",
+	"bmi1":                "BMI1",
+	"bmi2":                "BMI2",
+	"cmov":                "CMOV",
+	"cx16":                "CX16",
+	"erms":                "ERMS",
+	"f16c":                "F16C",
+	"fma":                 "FMA3",
+	"fma4":                "FMA4",
+	"gfni":                "GFNI",
+	"hle":                 "HLE",
+	"ht":                  "HTT",
+	"mmx":                 "MMX",
+	"mmxext":              "MMXEXT",
+	"mpx":                 "MPX",
+	"nx":                  "NX",
+	"pclmulqdq":           "CLMUL",
+	"pni":                 "SSE3",
+	"popcnt":              "POPCNT",
+	"rdrand":              "RDRAND",
+	"rdseed":              "RDSEED",
+	"rdtscp":              "RDTSCP",
+	"rtm":                 "RTM",
+	"sgx":                 "SGX",
+	"sgx_lc":              "SGXLC",
+	"sha_ni":              "SHA",
+	"sse":                 "SSE",
+	"sse2":                "SSE2",
+	"sse4_1":              "SSE4.1",
+	"sse4_2":              "SSE4.2",
+	"sse4a":               "SSE4A",
+	"ssse3":               "SSSE3",
+	"tbm":                 "TBM",
+	"vaes":                "VAES",
+	"vmx":                 "VMX",
+	"vpclmulqdq":          "VPCLMULQDQ",
+	"xop":                 "XOP",
+}
+
+// Get CPU features from /proc/cpuinfo. Used as a fallback in environments
+// where the cpuid instruction is not usable.
+func getFeaturesFromCpuinfo() ([]string, error) {
+	// All CPUs are expected to have the same flags, so only the flags of
+	// the first CPU are parsed
+	flags, err := cpuinfoutils.Flags()
+	if err != nil {
+		return nil, err
+	}
+	if len(flags) == 0 {
+		return nil, fmt.Errorf("no CPU flags found in /proc/cpuinfo")
+	}
+
+	features := []string{}
+	for flag := range flags {
+		if name, ok := cpuinfoFlags[flag]; ok {
+			features = append(features, name)
+		}
+	}
+	return features, nil
+}
@@ -16,7 +16,31 @@ limitations under the License.
 
 package fake
 
-import "sigs.k8s.io/node-feature-discovery/source"
+import (
+	"fmt"
+	"math/rand"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+// Configuration file options
+type NFDConfig struct {
+	// Features to emit, feature name mapped to value
+	Features map[string]string `json:"features,omitempty"`
+	// Probability (0.0-1.0) of a discovery pass returning an error
+	ErrorRate float64 `json:"errorRate,omitempty"`
+	// Probability (0.0-1.0) of a discovery pass panicking
+	PanicRate float64 `json:"panicRate,omitempty"`
+}
+
+var Config = NFDConfig{}
+
+// Features emitted if none are configured
+var defaultFeatures = map[string]string{
+	"fakefeature1": "true",
+	"fakefeature2": "true",
+	"fakefeature3": "true",
+}
 
 // Source implements FeatureSource.
 type Source struct{}
@@ -24,13 +48,25 @@ type Source struct{}
 // Name returns an identifier string for this feature source.
 func (s Source) Name() string { return "fake" }
 
-// Discover returns feature names for some fake features.
+// Discover returns feature names for some fake features. The features, and
+// the rate of failures, are configurable in order to enable testing without
+// real hardware.
 func (s Source) Discover() (source.Features, error) {
-	// Adding three fake features.
-	features := source.Features{
-		"fakefeature1": true,
-		"fakefeature2": true,
-		"fakefeature3": true,
+	if Config.PanicRate > 0 && rand.Float64() < Config.PanicRate {
+		panic("fake panic error")
+	}
+	if Config.ErrorRate > 0 && rand.Float64() < Config.ErrorRate {
+		return nil, fmt.Errorf("fake error")
+	}
+
+	fakeFeatures := Config.Features
+	if fakeFeatures == nil {
+		fakeFeatures = defaultFeatures
+	}
+
+	features := source.Features{}
+	for name, value := range fakeFeatures {
+		features[name] = value
 	}
 
 	return features, nil
@@ -0,0 +1,44 @@
+// +build amd64
+
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpuidutils
+
+// CpuidRet holds the register values returned by the cpuid instruction
+type CpuidRet struct {
+	EAX, EBX, ECX, EDX uint32
+}
+
+// Cpuid executes the cpuid instruction with the given leaf (eax) and
+// sub-leaf (ecx) input values
+func Cpuid(eax, ecx uint32) CpuidRet {
+	r := CpuidRet{}
+	r.EAX, r.EBX, r.ECX, r.EDX = cpuidAsm(eax, ecx)
+	return r
+}
+
+func cpuidAsm(eax_arg, ecx_arg uint32) (eax, ebx, ecx, edx uint32)
+
+// Xgetbv returns the value of the extended control register with the given
+// index. Must only be used if the OS has enabled XSAVE, i.e. the OSXSAVE
+// flag is reported by cpuid.
+func Xgetbv(index uint32) uint64 {
+	eax, edx := xgetbvAsm(index)
+	return uint64(edx)<<32 | uint64(eax)
+}
+
+func xgetbvAsm(index uint32) (eax, edx uint32)
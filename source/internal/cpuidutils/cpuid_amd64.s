@@ -25,3 +25,10 @@ TEXT ·cpuidAsm(SB), 4, $0   // 4 = NOSPLIT
     MOVL    CX, ecx+16(FP)
     MOVL    DX, edx+20(FP)
     RET
+
+TEXT ·xgetbvAsm(SB), 4, $0  // 4 = NOSPLIT
+    MOVL    index+0(FP), CX
+    BYTE    $0x0f; BYTE $0x01; BYTE $0xd0   // XGETBV
+    MOVL    AX, eax+8(FP)
+    MOVL    DX, edx+12(FP)
+    RET
@@ -0,0 +1,82 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpuinfoutils
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// CPU holds the fields of the description of a CPU in /proc/cpuinfo
+type CPU map[string]string
+
+// ReadFirstCPU reads the description of the first CPU in /proc/cpuinfo, i.e.
+// the fields up to the first empty line. On s390x, this is the description of
+// the features shared by all CPUs. If a field is listed multiple times, the
+// first occurrence is returned.
+func ReadFirstCPU() (CPU, error) {
+	cpu := CPU{}
+
+	f, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if strings.TrimSpace(line) == "" {
+			if len(cpu) > 0 {
+				break
+			}
+			continue
+		}
+		fields := strings.SplitN(line, ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(fields[0])
+		if _, ok := cpu[key]; !ok {
+			cpu[key] = strings.TrimSpace(fields[1])
+		}
+	}
+	return cpu, s.Err()
+}
+
+// Flags returns the CPU flags, listed as "flags" on x86 and as "Features"
+// on Arm
+func (c CPU) Flags() map[string]bool {
+	flags := map[string]bool{}
+	for _, key := range []string{"flags", "Features"} {
+		for _, flag := range strings.Fields(c[key]) {
+			flags[flag] = true
+		}
+	}
+	return flags
+}
+
+// Flags reads the flags of the first CPU in /proc/cpuinfo. All CPUs are
+// expected to have the same flags.
+func Flags() (map[string]bool, error) {
+	cpu, err := ReadFirstCPU()
+	if err != nil {
+		return nil, err
+	}
+	return cpu.Flags(), nil
+}
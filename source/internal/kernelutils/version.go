@@ -0,0 +1,52 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kernelutils
+
+import (
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+// Regexp for parsing version components
+var versionRe = regexp.MustCompile(`^(?P<major>\d+)(\.(?P<minor>\d+))?(\.(?P<revision>\d+))?(-.*)?$`)
+
+// ParseVersion reads and parses the version of the running kernel. The full
+// version is returned as "full", and its components as "major", "minor" and
+// "revision".
+func ParseVersion() (map[string]string, error) {
+	version := map[string]string{}
+
+	// Open file for reading
+	raw, err := ioutil.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return nil, err
+	}
+
+	full := strings.TrimSpace(string(raw))
+	version["full"] = full
+
+	if m := versionRe.FindStringSubmatch(full); m != nil {
+		for i, name := range versionRe.SubexpNames() {
+			if i != 0 && name != "" {
+				version[name] = m[i]
+			}
+		}
+	}
+
+	return version, nil
+}
@@ -0,0 +1,32 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labelutils
+
+import "strconv"
+
+// FormatKiB formats a size given in kB in the largest binary unit that the
+// size is a multiple of, the same way as the hugepages-<size> resource names
+// of Kubernetes, e.g. 2Mi or 1Gi.
+func FormatKiB(kb uint64) string {
+	for _, unit := range []string{"Ki", "Mi", "Gi"} {
+		if kb < 1024 || kb%1024 != 0 || unit == "Gi" {
+			return strconv.FormatUint(kb, 10) + unit
+		}
+		kb /= 1024
+	}
+	return ""
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labelutils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Maximum length of a label value
+const maxValueLen = 63
+
+var invalidValueRe = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// Value turns a free-form string into a valid label value, e.g.
+// "Tesla V100-SXM2-16GB" into "Tesla-V100-SXM2-16GB". Characters not valid in
+// label values are replaced with dashes, and the value is truncated to 63
+// characters before trimming, so that it also starts and ends with an
+// alphanumeric character after truncation. An empty string is returned if no
+// valid characters remain.
+func Value(s string) string {
+	value := invalidValueRe.ReplaceAllString(strings.TrimSpace(s), "-")
+	if len(value) > maxValueLen {
+		value = value[:maxValueLen]
+	}
+	return strings.Trim(value, "-_.")
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pciutils
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+)
+
+const sysfsPciDevices = "/sys/bus/pci/devices"
+
+// Driver returns the name of the driver bound to a PCI device, or an empty
+// string if there is none
+func Driver(address string) string {
+	driver, err := os.Readlink(path.Join(sysfsPciDevices, address, "driver"))
+	if err != nil {
+		return ""
+	}
+	return path.Base(driver)
+}
+
+// GpuReady checks if a GPU bound to the given driver is usable, i.e. exposed
+// through DRM device nodes, or through the device nodes of the proprietary
+// NVIDIA driver
+func GpuReady(address, driver string) bool {
+	switch driver {
+	case "":
+		return false
+	case "nvidia":
+		_, err := os.Stat(path.Join("/proc/driver/nvidia/gpus", address))
+		return err == nil
+	}
+	drm, _ := ioutil.ReadDir(path.Join(sysfsPciDevices, address, "drm"))
+	return len(drm) > 0
+}
+
+// QatReady checks if a QAT device bound to the given driver is up, i.e. its
+// firmware has been loaded
+func QatReady(address, driver string) bool {
+	if driver == "" {
+		return false
+	}
+	// Only physical functions report their state
+	state, err := ioutil.ReadFile(path.Join(sysfsPciDevices, address, "qat", "state"))
+	if err != nil {
+		return true
+	}
+	return strings.TrimSpace(string(state)) == "up"
+}
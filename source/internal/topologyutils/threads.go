@@ -0,0 +1,75 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topologyutils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const sysfsCpuDir = "/sys/devices/system/cpu"
+
+// ThreadsPerCore returns the maximum number of online hardware threads of a
+// core, i.e. the SMT mode on POWER
+func ThreadsPerCore() (int, error) {
+	files, err := filepath.Glob(filepath.Join(sysfsCpuDir, "cpu[0-9]*/topology/thread_siblings_list"))
+	if err != nil {
+		return 0, err
+	}
+
+	max := 0
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return 0, err
+		}
+		n, err := cpuListLen(strings.TrimSpace(string(data)))
+		if err != nil {
+			return 0, err
+		}
+		if n > max {
+			max = n
+		}
+	}
+	return max, nil
+}
+
+// cpuListLen returns the number of CPUs in a CPU list, e.g. "0-3,8"
+func cpuListLen(list string) (int, error) {
+	n := 0
+	for _, r := range strings.Split(list, ",") {
+		if r == "" {
+			continue
+		}
+		bounds := strings.SplitN(r, "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return 0, fmt.Errorf("invalid CPU list %q", list)
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, err = strconv.Atoi(bounds[1]); err != nil || last < first {
+				return 0, fmt.Errorf("invalid CPU list %q", list)
+			}
+		}
+		n += last - first + 1
+	}
+	return n, nil
+}
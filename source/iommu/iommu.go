@@ -19,10 +19,26 @@ package iommu
 import (
 	"fmt"
 	"io/ioutil"
+	"log"
+	"path"
+	"strings"
 
 	"sigs.k8s.io/node-feature-discovery/source"
 )
 
+const iommuGroupsPath = "/sys/kernel/iommu_groups"
+
+// IOMMU drivers, by the name prefix of the IOMMU devices they register
+var iommuDrivers = []struct {
+	prefix string
+	driver string
+}{
+	{"dmar", "intel-iommu"},
+	{"ivhd", "amd-vi"},
+	{"smmu3.", "smmu-v3"},
+	{"smmu.", "smmu"},
+}
+
 // Implement FeatureSource interface
 type Source struct{}
 
@@ -37,8 +53,42 @@ func (s Source) Discover() (source.Features, error) {
 		return nil, fmt.Errorf("Failed to check for IOMMU support: %v", err)
 	}
 
-	if len(devices) > 0 {
-		features["enabled"] = true
+	if len(devices) == 0 {
+		return features, nil
+	}
+	features["enabled"] = true
+
+	for _, d := range iommuDrivers {
+		if strings.HasPrefix(devices[0].Name(), d.prefix) {
+			features["driver"] = d.driver
+			break
+		}
+	}
+
+	groups, err := ioutil.ReadDir(iommuGroupsPath)
+	if err != nil {
+		log.Printf("ERROR: failed to read IOMMU groups: %v", err)
+		return features, nil
+	}
+	features["groups"] = len(groups)
+
+	// In pass-through mode, e.g. with iommu=pt, devices are not translated
+	// by default, i.e. the default domain of the groups is of type
+	// "identity" instead of "DMA". Domain types are reported in Linux v5.0
+	// and later.
+	typed, identity := 0, 0
+	for _, group := range groups {
+		data, err := ioutil.ReadFile(path.Join(iommuGroupsPath, group.Name(), "type"))
+		if err != nil {
+			continue
+		}
+		typed++
+		if strings.TrimSpace(string(data)) == "identity" {
+			identity++
+		}
+	}
+	if typed > 0 {
+		features["passthrough"] = identity == typed
 	}
 
 	return features, nil
@@ -0,0 +1,99 @@
+/*
+Copyright 2017-2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kernel
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// Detect the cgroup hierarchy in use: "v2" for the unified hierarchy, "v1"
+// for the legacy hierarchies, and "hybrid" for legacy hierarchies with the
+// unified hierarchy mounted alongside, without controllers. The controllers
+// available, i.e. delegated to the cgroup namespace of NFD on v2, are
+// returned as well.
+func cgroupVersion() (string, []string, error) {
+	f, err := os.Open("/proc/self/mounts")
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+
+	v1, v2, unified := false, false, false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// <device> <mount point> <fs type> <options> ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || !strings.HasPrefix(fields[1], cgroupRoot) {
+			continue
+		}
+		switch {
+		case fields[2] == "cgroup2" && fields[1] == cgroupRoot:
+			v2 = true
+		case fields[2] == "cgroup2":
+			unified = true
+		case fields[2] == "cgroup":
+			v1 = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", nil, err
+	}
+
+	switch {
+	case v2:
+		data, err := ioutil.ReadFile(cgroupRoot + "/cgroup.controllers")
+		if err != nil {
+			return "v2", nil, err
+		}
+		return "v2", strings.Fields(string(data)), nil
+	case v1:
+		controllers, err := cgroupV1Controllers()
+		if unified {
+			return "hybrid", controllers, err
+		}
+		return "v1", controllers, err
+	}
+	return "", nil, nil
+}
+
+// List the enabled controllers attached to a legacy hierarchy
+func cgroupV1Controllers() ([]string, error) {
+	f, err := os.Open("/proc/cgroups")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	controllers := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// #subsys_name hierarchy num_cgroups enabled
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 4 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if fields[1] != "0" && fields[3] == "1" {
+			controllers = append(controllers, fields[0])
+		}
+	}
+	return controllers, scanner.Err()
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2017-2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kernel
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source/internal/labelutils"
+)
+
+// Read the kernel command line parameters, of which only the last occurrence
+// is returned. Parameters without a value, e.g. "nosmt", get the value
+// "true". Commas, e.g. of CPU lists, are replaced with underscores, so that
+// ranges stay distinguishable, e.g. "isolcpus=1-3,5" becomes "1-3_5". Other
+// characters not valid in label values are replaced with dashes.
+func parseCmdline() (map[string]string, error) {
+	cmdline := map[string]string{}
+
+	data, err := ioutil.ReadFile("/proc/cmdline")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, param := range strings.Fields(string(data)) {
+		// Parameters after "--" are passed to init
+		if param == "--" {
+			break
+		}
+		split := strings.SplitN(param, "=", 2)
+		value := "true"
+		if len(split) == 2 {
+			value = labelutils.Value(strings.Replace(strings.Trim(split[1], `"`), ",", "_", -1))
+		}
+		// Dashes and underscores are equivalent in parameter names
+		cmdline[strings.Replace(split[0], "-", "_", -1)] = value
+	}
+
+	return cmdline, nil
+}
@@ -26,12 +26,14 @@ import (
 	"strings"
 
 	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/kernelutils"
 )
 
 // Configuration file options
 type NFDConfig struct {
-	KconfigFile string
-	ConfigOpts  []string `json:"configOpts,omitempty"`
+	KconfigFile   string
+	ConfigOpts    []string `json:"configOpts,omitempty"`
+	CmdlineParams []string `json:"cmdlineParams,omitempty"`
 }
 
 var logger = log.New(os.Stderr, "", log.LstdFlags)
@@ -44,6 +46,13 @@ var Config = NFDConfig{
 		"NO_HZ_FULL",
 		"PREEMPT",
 	},
+	CmdlineParams: []string{
+		"hugepages",
+		"isolcpus",
+		"mitigations",
+		"nohz_full",
+		"nosmt",
+	},
 }
 
 // Implement FeatureSource interface
@@ -55,7 +64,7 @@ func (s Source) Discover() (source.Features, error) {
 	features := source.Features{}
 
 	// Read kernel version
-	version, err := parseVersion()
+	version, err := kernelutils.ParseVersion()
 	if err != nil {
 		logger.Printf("ERROR: Failed to get kernel version: %s", err)
 	} else {
@@ -77,40 +86,46 @@ func (s Source) Discover() (source.Features, error) {
 		}
 	}
 
-	selinux, err := SelinuxEnabled()
+	// Check kernel command line parameters
+	cmdline, err := parseCmdline()
 	if err != nil {
-		logger.Print(err)
-	} else if selinux {
-		features["selinux.enabled"] = true
+		logger.Printf("ERROR: Failed to read kernel command line: %s", err)
+	}
+	for _, param := range Config.CmdlineParams {
+		if value, ok := cmdline[param]; ok && value != "" {
+			features["cmdline."+param] = value
+		}
 	}
 
-	return features, nil
-}
-
-// Read and parse kernel version
-func parseVersion() (map[string]string, error) {
-	version := map[string]string{}
+	if isRealtime(kconfig) {
+		features["realtime"] = true
+	}
 
-	// Open file for reading
-	raw, err := ioutil.ReadFile("/proc/sys/kernel/osrelease")
+	cgroup, controllers, err := cgroupVersion()
 	if err != nil {
-		return nil, err
+		logger.Printf("ERROR: Failed to detect cgroup hierarchy: %s", err)
+	}
+	if cgroup != "" {
+		features["cgroup.version"] = cgroup
+	}
+	for _, controller := range controllers {
+		features["cgroup.controller."+controller] = true
 	}
 
-	full := strings.TrimSpace(string(raw))
-	version["full"] = full
-
-	// Regexp for parsing version components
-	re := regexp.MustCompile(`^(?P<major>\d+)(\.(?P<minor>\d+))?(\.(?P<revision>\d+))?(-.*)?$`)
-	if m := re.FindStringSubmatch(full); m != nil {
-		for i, name := range re.SubexpNames() {
-			if i != 0 && name != "" {
-				version[name] = m[i]
-			}
+	selinux, fallback, err := SelinuxMode()
+	if err != nil {
+		logger.Print(err)
+	} else {
+		features["selinux.mode"] = selinux
+		if selinux == SelinuxEnforcing {
+			features["selinux.enabled"] = true
+		}
+		if fallback {
+			features["selinux.source"] = "container"
 		}
 	}
 
-	return version, nil
+	return features, nil
 }
 
 // Read gzipped kernel config
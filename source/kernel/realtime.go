@@ -0,0 +1,55 @@
+/*
+Copyright 2017-2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kernel
+
+import (
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+// Kernel releases of realtime kernels, e.g. "4.19.0-6-rt-amd64",
+// "5.14.0-70.13.1.rt21.83.el9_0.x86_64" or "5.15.0-1032-realtime"
+var realtimeReleaseRe = regexp.MustCompile(`[-.](rt[0-9]*|realtime)([-.]|$)`)
+
+// Detect if the kernel is a realtime kernel, i.e. built with the PREEMPT_RT
+// patch set
+func isRealtime(kconfig map[string]bool) bool {
+	// Only exists in PREEMPT_RT kernels
+	if data, err := ioutil.ReadFile("/sys/kernel/realtime"); err == nil {
+		return strings.TrimSpace(string(data)) == "1"
+	}
+
+	if kconfig["PREEMPT_RT"] || kconfig["PREEMPT_RT_FULL"] {
+		return true
+	}
+
+	// The build version, e.g. "#1 SMP PREEMPT_RT Debian ...", tells the
+	// preemption model since Linux v5.4, older RT kernels report
+	// "PREEMPT RT"
+	if data, err := ioutil.ReadFile("/proc/sys/kernel/version"); err == nil {
+		version := string(data)
+		if strings.Contains(version, "PREEMPT_RT") || strings.Contains(version, "PREEMPT RT") {
+			return true
+		}
+	}
+
+	if data, err := ioutil.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		return realtimeReleaseRe.MatchString(strings.TrimSpace(string(data)))
+	}
+	return false
+}
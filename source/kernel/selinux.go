@@ -19,17 +19,52 @@ package kernel
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
+	"sync"
 )
 
-// Detect if selinux has been enabled in the kernel
-func SelinuxEnabled() (bool, error) {
+// SELinux modes
+const (
+	SelinuxEnforcing  = "enforcing"
+	SelinuxPermissive = "permissive"
+	SelinuxDisabled   = "disabled"
+)
+
+// A missing host sysfs mount is a property of the deployment
+var selinuxFallbackLog sync.Once
+
+// Detect the mode selinux is running in. fallback is true if the mode was
+// detected from the selinuxfs of the container, instead of the host.
+func SelinuxMode() (mode string, fallback bool, err error) {
+	// Fall back to the selinuxfs possibly mounted into the container by the
+	// container runtime if the host sysfs is not available
+	if _, err := os.Stat("/host-sys/fs"); err != nil {
+		status, err := ioutil.ReadFile("/sys/fs/selinux/enforce")
+		if err != nil {
+			return "", false, fmt.Errorf("Failed to detect the status of selinux, please check if the system supports selinux and make sure /sys on the host is mounted into the container: %s", err.Error())
+		}
+		selinuxFallbackLog.Do(func() {
+			logger.Printf("host sysfs not available, detecting selinux status from /sys/fs/selinux")
+		})
+		return parseSelinuxEnforce(status), true, nil
+	}
+
+	// The selinuxfs is not mounted at all if selinux is disabled
+	if _, err := os.Stat("/host-sys/fs/selinux"); os.IsNotExist(err) {
+		return SelinuxDisabled, false, nil
+	}
+
 	status, err := ioutil.ReadFile("/host-sys/fs/selinux/enforce")
 	if err != nil {
-		return false, fmt.Errorf("Failed to detect the status of selinux, please check if the system supports selinux and make sure /sys on the host is mounted into the container: %s", err.Error())
+		return "", false, fmt.Errorf("Failed to detect the status of selinux: %s", err.Error())
 	}
-	if status[0] == byte('1') {
-		// selinux is enabled.
-		return true, nil
+	return parseSelinuxEnforce(status), false, nil
+}
+
+// Parse the content of the selinuxfs enforce file
+func parseSelinuxEnforce(status []byte) string {
+	if len(status) > 0 && status[0] == byte('1') {
+		return SelinuxEnforcing
 	}
-	return false, nil
+	return SelinuxPermissive
 }
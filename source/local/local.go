@@ -40,6 +40,10 @@ type Source struct{}
 
 func (s Source) Name() string { return "local" }
 
+// Priority returns the priority of the local source. It is ordered after all
+// other sources so that hooks are able to override labels from them.
+func (s Source) Priority() int { return 100 }
+
 func (s Source) Discover() (source.Features, error) {
 	features := source.Features{}
 
@@ -0,0 +1,151 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/labelutils"
+)
+
+const (
+	sysfsCxlDevices = "/sys/bus/cxl/devices"
+	sysfsPciDevices = "/sys/bus/pci/devices"
+
+	// PCIe extended capability ID of Designated Vendor-Specific Extended
+	// Capabilities (DVSEC)
+	pciExtCapIdDvsec = 0x23
+	// Vendor ID and DVSEC ID of the CXL device DVSEC, from the CXL
+	// specification
+	cxlDvsecVendorId = 0x1e98
+	cxlDvsecDeviceId = 0
+	// Cache_Capable bit of the CXL capability register of the DVSEC
+	cxlCapCache = 1 << 0
+)
+
+// discoverCxl detects Compute Express Link (CXL) ports, and CXL memory
+// devices (type 3), i.e. memory expanders, with their total volatile and
+// persistent capacity. CXL accelerators (type 1 and type 2 devices) are
+// detected separately, as they are not enumerated on the CXL bus.
+func discoverCxl() (source.Features, error) {
+	features := source.Features{}
+
+	if n := cxlAccelerators(); n > 0 {
+		features["cxl.accelerators"] = n
+	}
+
+	devices, err := ioutil.ReadDir(sysfsCxlDevices)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return features, nil
+		}
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return features, nil
+	}
+	features["cxl.present"] = true
+
+	memdevs := 0
+	var ram, pmem uint64
+	for _, dev := range devices {
+		name := dev.Name()
+		if !strings.HasPrefix(name, "mem") {
+			continue
+		}
+		memdevs++
+		ram += cxlCapacity(sysfsCxlDevices + "/" + name + "/ram/size")
+		pmem += cxlCapacity(sysfsCxlDevices + "/" + name + "/pmem/size")
+	}
+	if memdevs == 0 {
+		return features, nil
+	}
+
+	features["cxl.memdevs"] = memdevs
+	if ram > 0 {
+		features["cxl.ram"] = labelutils.FormatKiB(ram >> 10)
+	}
+	if pmem > 0 {
+		features["cxl.pmem"] = labelutils.FormatKiB(pmem >> 10)
+	}
+
+	return features, nil
+}
+
+// cxlAccelerators returns the number of CXL accelerators, i.e. PCI devices
+// with a CXL device DVSEC reporting that the device is cache capable. Type 1
+// and type 2 devices are cache capable, memory expanders (type 3) are not. The
+// extended PCI configuration space is only readable with CAP_SYS_ADMIN.
+func cxlAccelerators() int {
+	devices, err := ioutil.ReadDir(sysfsPciDevices)
+	if err != nil {
+		return 0
+	}
+
+	n := 0
+	for _, dev := range devices {
+		config, err := ioutil.ReadFile(path.Join(sysfsPciDevices, dev.Name(), "config"))
+		if err != nil {
+			continue
+		}
+		if cap, ok := cxlDvsecCapability(config); ok && cap&cxlCapCache != 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// cxlDvsecCapability walks the PCIe extended capabilities in the given
+// configuration space, and returns the CXL capability register of the CXL
+// device DVSEC, if found.
+func cxlDvsecCapability(config []byte) (uint16, bool) {
+	// Extended capabilities start at offset 0x100, each header holding the
+	// capability ID in bits 15:0 and the offset of the next one in 31:20
+	for offset, hops := 0x100, 0; offset >= 0x100 && offset+12 <= len(config) && hops < 512; hops++ {
+		header := binary.LittleEndian.Uint32(config[offset:])
+		if header == 0 || header == 0xffffffff {
+			break
+		}
+		if header&0xffff == pciExtCapIdDvsec &&
+			binary.LittleEndian.Uint16(config[offset+4:]) == cxlDvsecVendorId &&
+			binary.LittleEndian.Uint16(config[offset+8:]) == cxlDvsecDeviceId {
+			return binary.LittleEndian.Uint16(config[offset+10:]), true
+		}
+		offset = int(header >> 20)
+	}
+	return 0, false
+}
+
+// Read the size of a partition of a CXL memory device, reported in bytes in
+// hex
+func cxlCapacity(file string) uint64 {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return 0
+	}
+	size, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"), 16, 64)
+	if err != nil {
+		return 0
+	}
+	return size
+}
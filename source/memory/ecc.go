@@ -0,0 +1,53 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+const sysfsEdacMc = "/sys/devices/system/edac/mc"
+
+// eccEnabled returns true if an EDAC driver has registered a memory controller
+// with ECC enabled. Memory controllers are only registered if ECC is enabled,
+// but the EDAC mode of the DIMMs is checked, too, if reported by the driver.
+func eccEnabled() bool {
+	mcs, _ := filepath.Glob(sysfsEdacMc + "/mc[0-9]*")
+	for _, mc := range mcs {
+		modes, _ := filepath.Glob(mc + "/dimm[0-9]*/dimm_edac_mode")
+		if len(modes) == 0 {
+			modes, _ = filepath.Glob(mc + "/rank[0-9]*/dimm_edac_mode")
+		}
+		if len(modes) == 0 {
+			return true
+		}
+		for _, m := range modes {
+			data, err := ioutil.ReadFile(m)
+			if err != nil {
+				continue
+			}
+			switch strings.TrimSpace(string(data)) {
+			case "", "None", "Unknown":
+			default:
+				return true
+			}
+		}
+	}
+	return false
+}
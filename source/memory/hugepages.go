@@ -0,0 +1,66 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/labelutils"
+)
+
+const sysfsHugepages = "/sys/kernel/mm/hugepages"
+
+// discoverHugepages returns the number of configured hugepages of each
+// supported hugepage size.
+func discoverHugepages() (source.Features, error) {
+	features := source.Features{}
+
+	dirs, err := ioutil.ReadDir(sysfsHugepages)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return features, nil
+		}
+		return nil, err
+	}
+
+	for _, dir := range dirs {
+		// Directory names are of the form hugepages-<size>kB
+		size, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(dir.Name(), "hugepages-"), "kB"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hugepage directory %q", dir.Name())
+		}
+		data, err := ioutil.ReadFile(sysfsHugepages + "/" + dir.Name() + "/nr_hugepages")
+		if err != nil {
+			return nil, err
+		}
+		count, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number of %s hugepages: %s", dir.Name(), err)
+		}
+		if count > 0 {
+			name := "hugepages-" + labelutils.FormatKiB(size)
+			features[name+".present"] = true
+			features[name+".count"] = count
+		}
+	}
+	return features, nil
+}
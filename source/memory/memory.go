@@ -19,18 +19,32 @@ package memory
 import (
 	"fmt"
 	"io/ioutil"
+	"log"
 	"strings"
 
 	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/labelutils"
 )
 
+// Configuration file options
+type NFDConfig struct {
+	// Memory size tiers, e.g. 64Gi, of the size feature
+	SizeTiers []string `json:"sizeTiers,omitempty"`
+}
+
+var Config = NFDConfig{
+	SizeTiers: []string{"4Gi", "8Gi", "16Gi", "32Gi", "64Gi", "128Gi", "256Gi", "512Gi", "1Ti", "2Ti", "4Ti"},
+}
+
 // Source implements FeatureSource.
 type Source struct{}
 
 // Name returns an identifier string for this feature source.
 func (s Source) Name() string { return "memory" }
 
-// Discover returns feature names for memory: numa if more than one memory node is present.
+// Discover returns feature names for memory: numa if more than one memory node is present,
+// the size tier of the total memory, swap, ecc if ECC memory is in use, the configured
+// hugepages, persistent memory and CXL memory devices.
 func (s Source) Discover() (source.Features, error) {
 	features := source.Features{}
 
@@ -49,5 +63,52 @@ func (s Source) Discover() (source.Features, error) {
 		features["numa"] = true
 	}
 
+	tier, err := sizeTier(Config.SizeTiers)
+	if err != nil {
+		return nil, fmt.Errorf("can't determine memory size tier: %s", err.Error())
+	}
+	if tier != "" {
+		features["size"] = tier
+	}
+
+	// Swap is labeled also when disabled, as nodes with swap enabled are
+	// most likely misconfigured
+	swap, err := swapSize()
+	if err != nil {
+		return nil, fmt.Errorf("can't read /proc/swaps: %s", err.Error())
+	}
+	features["swap"] = swap > 0
+	if swap > 0 {
+		features["swap.size"] = labelutils.FormatKiB(swap)
+	}
+
+	if eccEnabled() {
+		features["ecc"] = true
+	}
+
+	hugepages, err := discoverHugepages()
+	if err != nil {
+		return nil, fmt.Errorf("can't detect hugepages: %s", err.Error())
+	}
+	for k, v := range hugepages {
+		features[k] = v
+	}
+
+	nvdimm, err := discoverNvdimm()
+	if err != nil {
+		return nil, fmt.Errorf("can't detect NVDIMMs: %s", err.Error())
+	}
+	for k, v := range nvdimm {
+		features[k] = v
+	}
+
+	cxl, err := discoverCxl()
+	if err != nil {
+		log.Printf("ERROR: can't detect CXL devices: %s", err.Error())
+	}
+	for k, v := range cxl {
+		features[k] = v
+	}
+
 	return features, nil
 }
@@ -0,0 +1,60 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+const sysfsNdDevices = "/sys/bus/nd/devices"
+
+// discoverNvdimm detects persistent memory regions, and namespaces that
+// support direct access (DAX).
+func discoverNvdimm() (source.Features, error) {
+	features := source.Features{}
+
+	devices, err := ioutil.ReadDir(sysfsNdDevices)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return features, nil
+		}
+		return nil, err
+	}
+
+	for _, dev := range devices {
+		name := dev.Name()
+		switch {
+		case strings.HasPrefix(name, "region"):
+			features["nv.present"] = true
+		case strings.HasPrefix(name, "namespace"):
+			data, err := ioutil.ReadFile(sysfsNdDevices + "/" + name + "/mode")
+			if err != nil {
+				continue
+			}
+			// "memory" and "dax" are the names used by old ndctl versions
+			switch strings.TrimSpace(string(data)) {
+			case "fsdax", "devdax", "memory", "dax":
+				features["nv.dax"] = true
+			}
+		}
+	}
+	return features, nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var sizeUnits = map[string]uint64{
+	"Ki": 1 << 10,
+	"Mi": 1 << 20,
+	"Gi": 1 << 30,
+	"Ti": 1 << 40,
+}
+
+// parseSize parses a memory size given in binary units, e.g. 256Gi
+func parseSize(s string) (uint64, error) {
+	if len(s) > 2 {
+		if mult, ok := sizeUnits[s[len(s)-2:]]; ok {
+			n, err := strconv.ParseUint(s[:len(s)-2], 10, 64)
+			if err == nil {
+				return n * mult, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid memory size %q", s)
+}
+
+// Fraction of the installed memory, as 1/n, that may be reserved by the
+// firmware and the kernel, and is thus missing from the total memory usable
+// by the kernel
+const reservedMemoryFraction = 16
+
+// sizeTier returns the largest of the tiers that the total memory of the node
+// reaches, or an empty string if it is smaller than all tiers. Memory
+// installed on the node is always somewhat more than the total memory usable
+// by the kernel, so a tier is reached if the total memory is at most 1/16
+// smaller than the tier, e.g. a node with 256 GiB of RAM, of which 250 GiB
+// are usable, falls into the 256Gi tier, and so does a node with 384 GiB.
+func sizeTier(tiers []string) (string, error) {
+	if len(tiers) == 0 {
+		return "", nil
+	}
+
+	total, err := memTotal()
+	if err != nil {
+		return "", err
+	}
+
+	type tier struct {
+		name string
+		size uint64
+	}
+	sorted := make([]tier, 0, len(tiers))
+	for _, t := range tiers {
+		size, err := parseSize(t)
+		if err != nil {
+			return "", err
+		}
+		sorted = append(sorted, tier{t, size})
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].size > sorted[j].size })
+
+	for _, t := range sorted {
+		if total >= t.size-t.size/reservedMemoryFraction {
+			return t.name, nil
+		}
+	}
+	return "", nil
+}
+
+// memTotal returns the total usable memory of the node in bytes
+func memTotal() (uint64, error) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// Line is of the form "MemTotal:       16324176 kB"
+		fields := strings.Fields(s.Text())
+		if len(fields) == 3 && fields[0] == "MemTotal:" && fields[2] == "kB" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid MemTotal in /proc/meminfo: %s", err)
+			}
+			return kb << 10, nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("MemTotal not found in /proc/meminfo")
+}
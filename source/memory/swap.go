@@ -0,0 +1,52 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// swapSize returns the total size of active swap areas in kB
+func swapSize() (uint64, error) {
+	f, err := os.Open("/proc/swaps")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	total := uint64(0)
+	s := bufio.NewScanner(f)
+	// Skip the header line
+	s.Scan()
+	for s.Scan() {
+		// Line is of the form "Filename Type Size Used Priority"
+		fields := strings.Fields(s.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		size, err := strconv.ParseUint(fields[2], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid swap size of %s: %s", fields[0], err)
+		}
+		total += size
+	}
+	return total, s.Err()
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+const sysfsPciDevices = "/sys/bus/pci/devices"
+
+// Userspace I/O drivers that DPDK poll mode drivers use for accessing a NIC
+var dpdkDrivers = map[string]bool{
+	"vfio-pci":        true,
+	"uio_pci_generic": true,
+	"igb_uio":         true,
+}
+
+// NICs with DPDK poll mode drivers, as PCI vendor:device pairs, from the
+// list of supported NICs of DPDK
+var dpdkDevices = map[string]bool{
+	// Intel
+	"0x8086:0x100e": true, // 82540EM (e1000)
+	"0x8086:0x1521": true, // I350
+	"0x8086:0x1533": true, // I210
+	"0x8086:0x10fb": true, // 82599ES
+	"0x8086:0x10ed": true, // 82599 VF
+	"0x8086:0x1528": true, // X540-AT2
+	"0x8086:0x1563": true, // X550T
+	"0x8086:0x1572": true, // X710 SFP+
+	"0x8086:0x1583": true, // XL710 QSFP+
+	"0x8086:0x1584": true, // XL710 QSFP+
+	"0x8086:0x1589": true, // X710 10GBASE-T
+	"0x8086:0x158b": true, // XXV710 SFP28
+	"0x8086:0x37d2": true, // X722 10GBASE-T
+	"0x8086:0x154c": true, // XL710/X710 VF
+	"0x8086:0x1889": true, // Adaptive VF
+	"0x8086:0x1592": true, // E810-C QSFP
+	"0x8086:0x1593": true, // E810-C SFP
+	"0x8086:0x159b": true, // E810-XXV SFP
+	// Mellanox
+	"0x15b3:0x1013": true, // ConnectX-4
+	"0x15b3:0x1015": true, // ConnectX-4 Lx
+	"0x15b3:0x1016": true, // ConnectX-4 Lx VF
+	"0x15b3:0x1017": true, // ConnectX-5
+	"0x15b3:0x1018": true, // ConnectX-5 VF
+	"0x15b3:0x1019": true, // ConnectX-5 Ex
+	"0x15b3:0x101b": true, // ConnectX-6
+	"0x15b3:0x101d": true, // ConnectX-6 Dx
+	"0x15b3:0xa2d6": true, // BlueField-2
+	// Broadcom
+	"0x14e4:0x16d7": true, // BCM57414
+	"0x14e4:0x16d8": true, // BCM57416
+	// Solarflare
+	"0x1924:0x0903": true, // SFC9120
+	"0x1924:0x0923": true, // SFC9140
+	// Netronome
+	"0x19ee:0x4000": true, // NFP4000
+	"0x19ee:0x6000": true, // NFP6000
+	// Huawei
+	"0x19e5:0x1822": true, // Hi1822
+	// Virtual NICs
+	"0x1af4:0x1000": true, // virtio-net (legacy)
+	"0x1af4:0x1041": true, // virtio-net
+	"0x15ad:0x07b0": true, // VMware VMXNET3
+	"0x1d0f:0x0ec2": true, // Amazon ENA
+	"0x1d0f:0x1ec2": true, // Amazon ENA
+	"0x1d0f:0xec20": true, // Amazon ENA VF
+	"0x1d0f:0xec21": true, // Amazon ENA VF
+}
+
+// Paths that tell that a NIC can be assigned to a userspace I/O driver, i.e.
+// that the IOMMU is enabled or that VFIO is available
+var dpdkIommuPaths = []string{
+	"/sys/kernel/iommu_groups",
+	"/sys/module/vfio_pci",
+}
+
+// dpdkIommuUsable returns true if the IOMMU is enabled, i.e. IOMMU groups
+// have been set up, or the VFIO PCI driver is loaded.
+func dpdkIommuUsable() bool {
+	for _, p := range dpdkIommuPaths {
+		if entries, err := ioutil.ReadDir(p); err == nil && len(entries) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// dpdkCapable returns true if any network controller is bound to a userspace
+// I/O driver, or is supported by DPDK and could thus be bound to one, given a
+// usable IOMMU or VFIO.
+func dpdkCapable() (bool, error) {
+	devices, err := ioutil.ReadDir(sysfsPciDevices)
+	if err != nil {
+		return false, err
+	}
+
+	iommuUsable := dpdkIommuUsable()
+	for _, device := range devices {
+		devPath := path.Join(sysfsPciDevices, device.Name())
+		class, err := ioutil.ReadFile(path.Join(devPath, "class"))
+		if err != nil {
+			glog.Errorf("Failed to read class of PCI device %s: %v", device.Name(), err)
+			continue
+		}
+		// Network controllers have class code 0x02
+		if !strings.HasPrefix(strings.TrimSpace(string(class)), "0x02") {
+			continue
+		}
+
+		if driver, err := os.Readlink(path.Join(devPath, "driver")); err == nil && dpdkDrivers[path.Base(driver)] {
+			glog.Infof("Network controller %s is bound to DPDK compatible driver %s", device.Name(), path.Base(driver))
+			return true, nil
+		}
+
+		if !iommuUsable {
+			continue
+		}
+		vendor, err := ioutil.ReadFile(path.Join(devPath, "vendor"))
+		if err != nil {
+			glog.Errorf("Failed to read vendor of PCI device %s: %v", device.Name(), err)
+			continue
+		}
+		dev, err := ioutil.ReadFile(path.Join(devPath, "device"))
+		if err != nil {
+			glog.Errorf("Failed to read device ID of PCI device %s: %v", device.Name(), err)
+			continue
+		}
+		if dpdkDevices[strings.TrimSpace(string(vendor))+":"+strings.TrimSpace(string(dev))] {
+			glog.Infof("Network controller %s is supported by DPDK", device.Name())
+			return true, nil
+		}
+	}
+	return false, nil
+}
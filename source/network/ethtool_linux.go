@@ -0,0 +1,218 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"bytes"
+	"syscall"
+	"unsafe"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+const (
+	SIOCETHTOOL = 0x8946
+
+	// ethtool commands, from linux/ethtool.h
+	ETHTOOL_GDRVINFO  = 0x00000003
+	ETHTOOL_GRXCSUM   = 0x00000014
+	ETHTOOL_GTXCSUM   = 0x00000016
+	ETHTOOL_GTSO      = 0x0000001e
+	ETHTOOL_GFLAGS    = 0x00000025
+	ETHTOOL_GGRO      = 0x0000002b
+	ETHTOOL_GCHANNELS = 0x0000003c
+	ETHTOOL_GTSINFO   = 0x00000041
+
+	// ETHTOOL_GFLAGS bitmasks
+	ETH_FLAG_LRO = 1 << 15
+
+	// SO_TIMESTAMPING flags, from linux/net_tstamp.h
+	SOF_TIMESTAMPING_TX_HARDWARE  = 1 << 0
+	SOF_TIMESTAMPING_RX_HARDWARE  = 1 << 2
+	SOF_TIMESTAMPING_RAW_HARDWARE = 1 << 6
+
+	ifNameSize = 16
+
+	ethtoolStrLen = 32
+)
+
+// struct ifreq with a pointer to ethtool command data
+type ifreq struct {
+	name [ifNameSize]byte
+	data unsafe.Pointer
+	_    [16]byte
+}
+
+// struct ethtool_value
+type ethtoolValue struct {
+	cmd  uint32
+	data uint32
+}
+
+// struct ethtool_drvinfo
+type ethtoolDrvinfo struct {
+	cmd         uint32
+	driver      [ethtoolStrLen]byte
+	version     [ethtoolStrLen]byte
+	fwVersion   [ethtoolStrLen]byte
+	busInfo     [ethtoolStrLen]byte
+	eromVersion [ethtoolStrLen]byte
+	reserved2   [12]byte
+	nPrivFlags  uint32
+	nStats      uint32
+	testinfoLen uint32
+	eedumpLen   uint32
+	regdumpLen  uint32
+}
+
+// struct ethtool_channels
+type ethtoolChannels struct {
+	cmd           uint32
+	maxRx         uint32
+	maxTx         uint32
+	maxOther      uint32
+	maxCombined   uint32
+	rxCount       uint32
+	txCount       uint32
+	otherCount    uint32
+	combinedCount uint32
+}
+
+// struct ethtool_ts_info
+type ethtoolTsInfo struct {
+	cmd            uint32
+	soTimestamping uint32
+	phcIndex       int32
+	txTypes        uint32
+	txReserved     [3]uint32
+	rxFilters      uint32
+	rxReserved     [3]uint32
+}
+
+// nicOffloads holds the offload capabilities of one network interface
+type nicOffloads struct {
+	tso        bool
+	gro        bool
+	lro        bool
+	rxChecksum bool
+	txChecksum bool
+	rxQueues   uint32
+}
+
+// Get the offloads enabled on a network interface, using the ethtool ioctl
+func getOffloads(ifName string) (nicOffloads, error) {
+	offloads := nicOffloads{}
+
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, 0)
+	if err != nil {
+		return offloads, err
+	}
+	defer syscall.Close(fd)
+
+	value := func(cmd uint32) (uint32, error) {
+		v := ethtoolValue{cmd: cmd}
+		err := ethtoolIoctl(fd, ifName, unsafe.Pointer(&v))
+		return v.data, err
+	}
+
+	if v, err := value(ETHTOOL_GTSO); err == nil {
+		offloads.tso = v != 0
+	}
+	if v, err := value(ETHTOOL_GGRO); err == nil {
+		offloads.gro = v != 0
+	}
+	if v, err := value(ETHTOOL_GFLAGS); err == nil {
+		offloads.lro = v&ETH_FLAG_LRO != 0
+	}
+	if v, err := value(ETHTOOL_GRXCSUM); err == nil {
+		offloads.rxChecksum = v != 0
+	}
+	if v, err := value(ETHTOOL_GTXCSUM); err == nil {
+		offloads.txChecksum = v != 0
+	}
+
+	// Number of receive queues used for RSS
+	channels := ethtoolChannels{cmd: ETHTOOL_GCHANNELS}
+	if err := ethtoolIoctl(fd, ifName, unsafe.Pointer(&channels)); err == nil {
+		offloads.rxQueues = channels.combinedCount + channels.rxCount
+	}
+
+	return offloads, nil
+}
+
+// Check if a network interface supports hardware timestamping with a PTP
+// hardware clock, using the ethtool ioctl
+func getHwTimestamping(ifName string) (bool, error) {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, 0)
+	if err != nil {
+		return false, err
+	}
+	defer syscall.Close(fd)
+
+	info := ethtoolTsInfo{cmd: ETHTOOL_GTSINFO}
+	if err := ethtoolIoctl(fd, ifName, unsafe.Pointer(&info)); err != nil {
+		return false, err
+	}
+
+	hwFlags := uint32(SOF_TIMESTAMPING_TX_HARDWARE | SOF_TIMESTAMPING_RX_HARDWARE | SOF_TIMESTAMPING_RAW_HARDWARE)
+	return info.soTimestamping&hwFlags == hwFlags && info.phcIndex >= 0, nil
+}
+
+// Get the driver and firmware information of a network interface, using the
+// ethtool ioctl
+func getDriverInfo(ifName string) (source.Driver, error) {
+	driver := source.Driver{}
+
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, 0)
+	if err != nil {
+		return driver, err
+	}
+	defer syscall.Close(fd)
+
+	info := ethtoolDrvinfo{cmd: ETHTOOL_GDRVINFO}
+	if err := ethtoolIoctl(fd, ifName, unsafe.Pointer(&info)); err != nil {
+		return driver, err
+	}
+
+	driver.Device = cString(info.busInfo[:])
+	if driver.Device == "" {
+		driver.Device = ifName
+	}
+	driver.Driver = cString(info.driver[:])
+	driver.Version = cString(info.version[:])
+	driver.Firmware = cString(info.fwVersion[:])
+	return driver, nil
+}
+
+// cString converts a NUL terminated byte array into a string
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+func ethtoolIoctl(fd int, ifName string, data unsafe.Pointer) error {
+	req := ifreq{data: data}
+	copy(req.name[:ifNameSize-1], ifName)
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), SIOCETHTOOL, uintptr(unsafe.Pointer(&req)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
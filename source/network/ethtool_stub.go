@@ -0,0 +1,46 @@
+// +build !linux
+
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+type nicOffloads struct {
+	tso        bool
+	gro        bool
+	lro        bool
+	rxChecksum bool
+	txChecksum bool
+	rxQueues   uint32
+}
+
+func getOffloads(ifName string) (nicOffloads, error) {
+	return nicOffloads{}, fmt.Errorf("ethtool is only supported on Linux")
+}
+
+func getHwTimestamping(ifName string) (bool, error) {
+	return false, fmt.Errorf("ethtool is only supported on Linux")
+}
+
+func getDriverInfo(ifName string) (source.Driver, error) {
+	return source.Driver{}, fmt.Errorf("ethtool is only supported on Linux")
+}
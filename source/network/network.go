@@ -22,6 +22,8 @@ import (
 	"github.com/golang/glog"
 	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -41,46 +43,191 @@ func (s Source) Discover() (source.Features, error) {
 	if err != nil {
 		return nil, fmt.Errorf("can't obtain the network interfaces details: %s", err.Error())
 	}
+	totalVfs := 0
+	numVfs := 0
 	// iterating through network interfaces to obtain their respective number of virtual functions
 	for _, netInterface := range netInterfaces {
 		if strings.Contains(netInterface.Flags.String(), "up") && !strings.Contains(netInterface.Flags.String(), "loopback") {
 			totalVfsPath := "/sys/class/net/" + netInterface.Name + "/device/sriov_totalvfs"
-			totalBytes, err := ioutil.ReadFile(totalVfsPath)
+			t, err := readVfCount(totalVfsPath)
 			if err != nil {
 				glog.Errorf("SR-IOV not supported for network interface: %s: %v", netInterface.Name, err)
 				continue
 			}
-			total := bytes.TrimSpace(totalBytes)
-			t, err := strconv.Atoi(string(total))
-			if err != nil {
-				glog.Errorf("Error in obtaining maximum supported number of virtual functions for network interface: %s: %v", netInterface.Name, err)
-				continue
-			}
 			if t > 0 {
 				glog.Infof("SR-IOV capability is detected on the network interface: %s", netInterface.Name)
 				glog.Infof("%d maximum supported number of virtual functions on network interface: %s", t, netInterface.Name)
 				features["sriov.capable"] = true
+				totalVfs += t
 				numVfsPath := "/sys/class/net/" + netInterface.Name + "/device/sriov_numvfs"
-				numBytes, err := ioutil.ReadFile(numVfsPath)
+				n, err := readVfCount(numVfsPath)
 				if err != nil {
 					glog.Errorf("SR-IOV not configured for network interface: %s: %s", netInterface.Name, err)
 					continue
 				}
-				num := bytes.TrimSpace(numBytes)
-				n, err := strconv.Atoi(string(num))
-				if err != nil {
-					glog.Errorf("Error in obtaining the configured number of virtual functions for network interface: %s: %v", netInterface.Name, err)
-					continue
-				}
 				if n > 0 {
 					glog.Infof("%d virtual functions configured on network interface: %s", n, netInterface.Name)
 					features["sriov.configured"] = true
-					break
+					numVfs += n
+					// Virtual functions are not usable until a driver,
+					// e.g. iavf or vfio-pci, is bound to them
+					if vfDriverBound(netInterface.Name) {
+						features["sriov.driver_ready"] = true
+					}
 				} else if n == 0 {
 					glog.Errorf("SR-IOV not configured on network interface: %s", netInterface.Name)
 				}
 			}
 		}
 	}
+	if totalVfs > 0 {
+		features["sriov.totalvfs"] = totalVfs
+		features["sriov.numvfs"] = numVfs
+	}
+
+	for k, v := range discoverOffloads(netInterfaces) {
+		features[k] = v
+	}
+
+	if speed := maxLinkSpeed(netInterfaces); speed > 0 {
+		features["speed"] = speed
+	}
+
+	for k, v := range discoverPtp(netInterfaces) {
+		features[k] = v
+	}
+
+	model, err := smartnicModel()
+	if err != nil {
+		glog.Errorf("Failed to detect SmartNICs: %v", err)
+	} else if model != "" {
+		features["smartnic.present"] = true
+		features["smartnic.model"] = model
+	}
+
+	dpdk, err := dpdkCapable()
+	if err != nil {
+		glog.Errorf("Failed to detect DPDK capable network controllers: %v", err)
+	} else if dpdk {
+		features["dpdk.capable"] = true
+	}
+
 	return features, nil
 }
+
+// Drivers returns the drivers and firmware of the physical network interfaces
+func (s Source) Drivers() ([]source.Driver, error) {
+	netInterfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("can't obtain the network interfaces details: %s", err.Error())
+	}
+
+	drivers := []source.Driver{}
+	for _, netInterface := range netInterfaces {
+		if _, err := os.Stat("/sys/class/net/" + netInterface.Name + "/device"); err != nil {
+			continue
+		}
+		driver, err := getDriverInfo(netInterface.Name)
+		if err != nil {
+			glog.Errorf("Failed to get driver info of network interface: %s: %v", netInterface.Name, err)
+			continue
+		}
+		drivers = append(drivers, driver)
+	}
+	return drivers, nil
+}
+
+// discoverOffloads returns the offloads enabled on any of the physical network
+// interfaces, and the maximum number of RSS queues of an interface
+func discoverOffloads(netInterfaces []net.Interface) source.Features {
+	features := source.Features{}
+	rxQueues := uint32(0)
+
+	for _, netInterface := range netInterfaces {
+		if !strings.Contains(netInterface.Flags.String(), "up") || strings.Contains(netInterface.Flags.String(), "loopback") {
+			continue
+		}
+		// Only consider physical interfaces, backed by a device
+		if _, err := os.Stat("/sys/class/net/" + netInterface.Name + "/device"); err != nil {
+			continue
+		}
+		offloads, err := getOffloads(netInterface.Name)
+		if err != nil {
+			glog.Errorf("Failed to get offloads of network interface: %s: %v", netInterface.Name, err)
+			continue
+		}
+		if offloads.tso {
+			features["offload.tso"] = true
+		}
+		if offloads.gro {
+			features["offload.gro"] = true
+		}
+		if offloads.lro {
+			features["offload.lro"] = true
+		}
+		if offloads.rxChecksum {
+			features["offload.rx_checksum"] = true
+		}
+		if offloads.txChecksum {
+			features["offload.tx_checksum"] = true
+		}
+		if offloads.rxQueues > rxQueues {
+			rxQueues = offloads.rxQueues
+		}
+	}
+	if rxQueues > 0 {
+		features["rss.queues"] = rxQueues
+	}
+
+	return features
+}
+
+// maxLinkSpeed returns the highest link speed, in Mb/s, of the physical network
+// interfaces that are up
+func maxLinkSpeed(netInterfaces []net.Interface) int {
+	maxSpeed := 0
+	for _, netInterface := range netInterfaces {
+		if !strings.Contains(netInterface.Flags.String(), "up") || strings.Contains(netInterface.Flags.String(), "loopback") {
+			continue
+		}
+		if _, err := os.Stat("/sys/class/net/" + netInterface.Name + "/device"); err != nil {
+			continue
+		}
+		// Reading speed fails, or gives -1, if the link is down or the
+		// driver does not report it
+		data, err := ioutil.ReadFile("/sys/class/net/" + netInterface.Name + "/speed")
+		if err != nil {
+			glog.Infof("Link speed not available for network interface: %s: %v", netInterface.Name, err)
+			continue
+		}
+		speed, err := strconv.Atoi(string(bytes.TrimSpace(data)))
+		if err != nil {
+			glog.Errorf("Failed to parse link speed of network interface: %s: %v", netInterface.Name, err)
+			continue
+		}
+		if speed > maxSpeed {
+			maxSpeed = speed
+		}
+	}
+	return maxSpeed
+}
+
+// vfDriverBound returns true if a driver is bound to any of the virtual
+// functions of a network interface
+func vfDriverBound(ifName string) bool {
+	drivers, _ := filepath.Glob("/sys/class/net/" + ifName + "/device/virtfn*/driver")
+	return len(drivers) > 0
+}
+
+// readVfCount reads a number of virtual functions from a sysfs file
+func readVfCount(path string) (int, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(string(bytes.TrimSpace(data)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return n, nil
+}
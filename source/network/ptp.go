@@ -0,0 +1,65 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/golang/glog"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+// discoverPtp returns the number of PTP hardware clocks, and whether any of
+// the physical network interfaces supports hardware timestamping
+func discoverPtp(netInterfaces []net.Interface) source.Features {
+	features := source.Features{}
+
+	// PTP clocks are not necessarily provided by a NIC, e.g. ptp_kvm
+	clocks, err := ioutil.ReadDir("/sys/class/ptp")
+	if err != nil {
+		if !os.IsNotExist(err) {
+			glog.Errorf("Failed to list PTP clocks: %v", err)
+		}
+	} else if len(clocks) > 0 {
+		features["ptp.clocks"] = len(clocks)
+	}
+
+	for _, netInterface := range netInterfaces {
+		if strings.Contains(netInterface.Flags.String(), "loopback") {
+			continue
+		}
+		if _, err := os.Stat("/sys/class/net/" + netInterface.Name + "/device"); err != nil {
+			continue
+		}
+		hwTimestamping, err := getHwTimestamping(netInterface.Name)
+		if err != nil {
+			glog.Errorf("Failed to get timestamping capabilities of network interface: %s: %v", netInterface.Name, err)
+			continue
+		}
+		if hwTimestamping {
+			glog.Infof("Hardware timestamping is supported by network interface: %s", netInterface.Name)
+			features["ptp.capable"] = true
+			break
+		}
+	}
+
+	return features
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+// Models of SmartNICs/DPUs, indexed by PCI vendor and device ID
+var smartnicDevices = map[string]string{
+	"0x15b3:0xa2d2": "bluefield",  // NVIDIA BlueField integrated ConnectX-5
+	"0x15b3:0xa2d6": "bluefield2", // NVIDIA BlueField-2 integrated ConnectX-6 Dx
+	"0x15b3:0xa2dc": "bluefield3", // NVIDIA BlueField-3 integrated ConnectX-7
+	// The rshim management function of a BlueField DPU may be exposed to the
+	// host even if the DPU's network functions are not
+	"0x15b3:0xc2d2": "bluefield",  // NVIDIA BlueField SoC management interface (rshim)
+	"0x15b3:0xc2d3": "bluefield2", // NVIDIA BlueField-2 SoC management interface (rshim)
+	"0x15b3:0xc2d4": "bluefield3", // NVIDIA BlueField-3 SoC management interface (rshim)
+	"0x1dd8:0x1002": "pensando",   // Pensando DSC Ethernet controller
+	"0x1dd8:0x1004": "pensando",   // Pensando DSC management controller
+	"0x8086:0x1452": "ipu",        // Intel IPU E2000 (IDPF physical function)
+}
+
+// smartnicModel returns the model of the first SmartNIC/DPU found on the node,
+// or an empty string if there are none.
+func smartnicModel() (string, error) {
+	devices, err := ioutil.ReadDir(sysfsPciDevices)
+	if err != nil {
+		return "", err
+	}
+
+	for _, device := range devices {
+		devPath := path.Join(sysfsPciDevices, device.Name())
+		vendor, err := ioutil.ReadFile(path.Join(devPath, "vendor"))
+		if err != nil {
+			glog.Errorf("Failed to read vendor of PCI device %s: %v", device.Name(), err)
+			continue
+		}
+		devID, err := ioutil.ReadFile(path.Join(devPath, "device"))
+		if err != nil {
+			glog.Errorf("Failed to read device ID of PCI device %s: %v", device.Name(), err)
+			continue
+		}
+		id := strings.TrimSpace(string(vendor)) + ":" + strings.TrimSpace(string(devID))
+		if model, ok := smartnicDevices[id]; ok {
+			glog.Infof("SmartNIC %s detected: PCI device %s", model, device.Name())
+			return model, nil
+		}
+	}
+
+	return "", nil
+}
@@ -25,6 +25,7 @@ import (
 	"strings"
 
 	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/pciutils"
 )
 
 type pciDeviceInfo map[string]string
@@ -41,6 +42,8 @@ var Config = NFDConfig{
 	DeviceLabelFields:    []string{"class", "vendor"},
 }
 
+const sysfsPciDevices = "/sys/bus/pci/devices/"
+
 var devLabelAttrs = []string{"class", "vendor", "device", "subsystem_vendor", "subsystem_device"}
 
 // Implement FeatureSource interface
@@ -95,8 +98,12 @@ func (s Source) Discover() (source.Features, error) {
 							devLabel += "_"
 						}
 					}
-					devLabel += ".present"
-					features[devLabel] = true
+					features[devLabel+".present"] = true
+					// Hardware may be present but not usable, e.g.
+					// because of a missing driver or firmware
+					if driverReady(dev) {
+						features[devLabel+".driver_ready"] = true
+					}
 				}
 			}
 		}
@@ -128,7 +135,7 @@ func readDevInfo(devPath string) (pciDeviceInfo, error) {
 
 // List available PCI devices
 func detectPci() (map[string][]pciDeviceInfo, error) {
-	const basePath = "/sys/bus/pci/devices/"
+	const basePath = sysfsPciDevices
 	devInfo := make(map[string][]pciDeviceInfo)
 
 	devices, err := ioutil.ReadDir(basePath)
@@ -143,9 +150,30 @@ func detectPci() (map[string][]pciDeviceInfo, error) {
 			log.Print(err)
 			continue
 		}
+		info["address"] = device.Name()
 		class := info["class"]
 		devInfo[class] = append(devInfo[class], info)
 	}
 
 	return devInfo, nil
 }
+
+// Check if the driver of a PCI device is ready for use, i.e. a driver is
+// bound to the device and, for device families for which it can be verified,
+// the device is usable.
+func driverReady(dev pciDeviceInfo) bool {
+	driver := pciutils.Driver(dev["address"])
+	if driver == "" {
+		return false
+	}
+
+	switch {
+	case strings.HasPrefix(dev["class"], "03"):
+		return pciutils.GpuReady(dev["address"], driver)
+	case dev["class"] == "0b40":
+		// QAT devices are not usable until the firmware has been loaded
+		// and the device has been brought up
+		return pciutils.QatReady(dev["address"], driver)
+	}
+	return true
+}
@@ -20,36 +20,77 @@ import (
 	"fmt"
 	"io/ioutil"
 	"runtime"
+	"strings"
 
 	"sigs.k8s.io/node-feature-discovery/source"
 )
 
+// Attributes of the cpufreq policy of a CPU to publish. The hardware
+// frequency range is published, instead of the scaling limits that may be
+// adjusted at runtime, e.g. by power management daemons.
+var cpufreqAttrs = []string{
+	"scaling_driver",
+	"scaling_governor",
+	"cpuinfo_min_freq",
+	"cpuinfo_max_freq",
+}
+
 // Source implements FeatureSource.
 type Source struct{}
 
 // Name returns an identifier string for this feature source.
 func (s Source) Name() string { return "pstate" }
 
-// Discover returns feature names for p-state related features such as turbo boost.
+// Discover returns feature names for p-state related features such as turbo
+// boost and the frequency scaling governor.
 func (s Source) Discover() (source.Features, error) {
 	features := source.Features{}
 
+	// Frequency scaling of the first CPU
+	for _, attr := range cpufreqAttrs {
+		data, err := ioutil.ReadFile("/sys/devices/system/cpu/cpu0/cpufreq/" + attr)
+		if err != nil {
+			continue
+		}
+		if value := strings.TrimSpace(string(data)); value != "" {
+			features[attr] = value
+		}
+	}
+
 	// On Arm platform, the frequency boost mechanism is software-based.
-	// So skip pstate detection on Arm.
+	// So skip turbo detection on Arm.
 	switch runtime.GOARCH {
 	case "arm64":
 		return features, nil
 	}
 
-	// Only looking for turbo boost for now...
-	bytes, err := ioutil.ReadFile("/sys/devices/system/cpu/intel_pstate/no_turbo")
+	turbo, err := turboEnabled()
 	if err != nil {
-		return nil, fmt.Errorf("can't detect whether turbo boost is enabled: %s", err.Error())
-	}
-	if bytes[0] == byte('0') {
-		// Turbo boost is enabled.
+		if len(features) == 0 {
+			return nil, fmt.Errorf("can't detect whether turbo boost is enabled: %s", err.Error())
+		}
+	} else if turbo {
 		features["turbo"] = true
+	} else {
+		// turbo=false is not published, like in earlier versions of NFD,
+		// so that existing node selectors keep working. A separate label
+		// tells disabled turbo apart from nodes without turbo interface.
+		features["turbo.disabled"] = true
 	}
 
 	return features, nil
 }
+
+// Check if turbo boost is enabled, either in the intel_pstate driver or in
+// the generic cpufreq boost interface used e.g. by acpi-cpufreq
+func turboEnabled() (bool, error) {
+	bytes, err := ioutil.ReadFile("/sys/devices/system/cpu/intel_pstate/no_turbo")
+	if err == nil {
+		return strings.TrimSpace(string(bytes)) == "0", nil
+	}
+	bytes, err = ioutil.ReadFile("/sys/devices/system/cpu/cpufreq/boost")
+	if err == nil {
+		return strings.TrimSpace(string(bytes)) == "1", nil
+	}
+	return false, fmt.Errorf("neither intel_pstate nor cpufreq boost interface available")
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rdma
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+const (
+	sysfsInfiniband = "/sys/class/infiniband"
+	// HPE Slingshot NICs (Cassini), which are not RDMA devices of the
+	// kernel
+	sysfsCxi = "/sys/class/cxi"
+)
+
+// HPC fabrics of RDMA devices, by the driver of the device
+var fabricDrivers = map[string]string{
+	"hfi1": "opa", // Omni-Path
+	"efa":  "efa", // AWS Elastic Fabric Adapter
+}
+
+var logger = log.New(os.Stderr, "", log.LstdFlags)
+
+// Implement FeatureSource interface
+type Source struct{}
+
+func (s Source) Name() string { return "rdma" }
+
+func (s Source) Discover() (source.Features, error) {
+	features := source.Features{}
+
+	if cxi, err := ioutil.ReadDir(sysfsCxi); err == nil && len(cxi) > 0 {
+		features["fabric.slingshot"] = true
+	}
+
+	devices, err := ioutil.ReadDir(sysfsInfiniband)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return features, nil
+		}
+		return nil, fmt.Errorf("Failed to list RDMA devices: %v", err)
+	}
+	if len(devices) == 0 {
+		return features, nil
+	}
+
+	features["present"] = true
+	features["devices"] = len(devices)
+
+	for _, device := range devices {
+		if driver, err := os.Readlink(path.Join(sysfsInfiniband, device.Name(), "device", "driver")); err == nil {
+			if fabric, ok := fabricDrivers[path.Base(driver)]; ok {
+				features["fabric."+fabric] = true
+			}
+		}
+
+		portsDir := path.Join(sysfsInfiniband, device.Name(), "ports")
+		ports, err := ioutil.ReadDir(portsDir)
+		if err != nil {
+			logger.Printf("ERROR: failed to list ports of RDMA device %s: %s", device.Name(), err)
+			continue
+		}
+		for _, port := range ports {
+			data, err := ioutil.ReadFile(path.Join(portsDir, port.Name(), "link_layer"))
+			if err != nil {
+				logger.Printf("ERROR: failed to read link layer of RDMA device %s port %s: %s", device.Name(), port.Name(), err)
+				continue
+			}
+			// RoCE devices run on top of an Ethernet link layer
+			switch strings.TrimSpace(string(data)) {
+			case "InfiniBand":
+				features["transport.ib"] = true
+			case "Ethernet":
+				features["transport.roce"] = true
+			}
+		}
+	}
+
+	return features, nil
+}
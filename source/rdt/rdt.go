@@ -26,15 +26,8 @@ type Source struct{}
 // Name returns an identifier string for this feature source.
 func (s Source) Name() string { return "rdt" }
 
-// Discover returns feature names for CMT and CAT if supported.
+// Discover returns feature names for CMT and CAT if supported, and the
+// number of RMIDs and CLOSIDs available.
 func (s Source) Discover() (source.Features, error) {
-	features := source.Features{}
-
-	rdtFeatures := discoverRDT()
-
-	for _, f := range rdtFeatures {
-		features[f] = true
-	}
-
-	return features, nil
+	return discoverRDT(), nil
 }
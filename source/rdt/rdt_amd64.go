@@ -18,9 +18,10 @@ limitations under the License.
 
 package rdt
 
-type CpuidRet struct {
-	EAX, EBX, ECX, EDX uint32
-}
+import (
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/cpuidutils"
+)
 
 const (
 	// CPUID EAX input values
@@ -30,6 +31,9 @@ const (
 
 	// CPUID ECX input values
 	RDT_MONITORING_SUBLEAF_L3 = 1
+	RDT_ALLOCATION_SUBLEAF_L3 = 1
+	RDT_ALLOCATION_SUBLEAF_L2 = 2
+	RDT_ALLOCATION_SUBLEAF_MB = 3
 
 	// CPUID bitmasks
 	EXT_FEATURE_FLAGS_EBX_RDT_M                                 = 1 << 12
@@ -41,31 +45,41 @@ const (
 	RDT_ALLOCATION_EBX_L3_CACHE_ALLOCATION                      = 1 << 1
 	RDT_ALLOCATION_EBX_L2_CACHE_ALLOCATION                      = 1 << 2
 	RDT_ALLOCATION_EBX_MEMORY_BANDWIDTH_ALLOCATION              = 1 << 3
+	RDT_ALLOCATION_EDX_HIGHEST_COS_MASK                         = 0xffff
 )
 
-func discoverRDT() []string {
-	features := []string{}
+func discoverRDT() source.Features {
+	features := source.Features{}
 
 	// Read cpuid information
-	extFeatures := cpuid(LEAF_EXT_FEATURE_FLAGS, 0)
-	rdtMonitoring := cpuid(LEAF_RDT_MONITORING, 0)
-	rdtL3Monitoring := cpuid(LEAF_RDT_MONITORING, RDT_MONITORING_SUBLEAF_L3)
-	rdtAllocation := cpuid(LEAF_RDT_ALLOCATION, 0)
+	extFeatures := cpuidutils.Cpuid(LEAF_EXT_FEATURE_FLAGS, 0)
+	rdtMonitoring := cpuidutils.Cpuid(LEAF_RDT_MONITORING, 0)
+	rdtL3Monitoring := cpuidutils.Cpuid(LEAF_RDT_MONITORING, RDT_MONITORING_SUBLEAF_L3)
+	rdtAllocation := cpuidutils.Cpuid(LEAF_RDT_ALLOCATION, 0)
 
 	// Detect RDT monitoring capabilities
 	if extFeatures.EBX&EXT_FEATURE_FLAGS_EBX_RDT_M != 0 {
 		if rdtMonitoring.EDX&RDT_MONITORING_EDX_L3_MONITORING != 0 {
 			// Monitoring is supported
-			features = append(features, "RDTMON")
+			features["RDTMON"] = true
+			// Number of RMIDs available for L3 monitoring
+			features["RDTMON.rmids"] = rdtL3Monitoring.ECX + 1
 
 			// Cache Monitoring Technology (L3 occupancy monitoring)
 			if rdtL3Monitoring.EDX&RDT_MONITORING_SUBLEAF_L3_EDX_L3_OCCUPANCY_MONITORING != 0 {
-				features = append(features, "RDTCMT")
+				features["RDTCMT"] = true
 			}
 			// Memore Bandwidth Monitoring (L3 local&total bandwidth monitoring)
-			if rdtL3Monitoring.EDX&RDT_MONITORING_SUBLEAF_L3_EDX_L3_TOTAL_BANDWIDTH_MONITORING != 0 &&
-				rdtL3Monitoring.EDX&RDT_MONITORING_SUBLEAF_L3_EDX_L3_LOCAL_BANDWIDTH_MONITORING != 0 {
-				features = append(features, "RDTMBM")
+			mbmTotal := rdtL3Monitoring.EDX&RDT_MONITORING_SUBLEAF_L3_EDX_L3_TOTAL_BANDWIDTH_MONITORING != 0
+			mbmLocal := rdtL3Monitoring.EDX&RDT_MONITORING_SUBLEAF_L3_EDX_L3_LOCAL_BANDWIDTH_MONITORING != 0
+			if mbmTotal && mbmLocal {
+				features["RDTMBM"] = true
+			}
+			if mbmTotal {
+				features["RDTMBM.total"] = true
+			}
+			if mbmLocal {
+				features["RDTMBM.local"] = true
 			}
 		}
 	}
@@ -74,25 +88,25 @@ func discoverRDT() []string {
 	if extFeatures.EBX&EXT_FEATURE_FLAGS_EBX_RDT_A != 0 {
 		// L3 Cache Allocation
 		if rdtAllocation.EBX&RDT_ALLOCATION_EBX_L3_CACHE_ALLOCATION != 0 {
-			features = append(features, "RDTL3CA")
+			features["RDTL3CA"] = true
+			features["RDTL3CA.closids"] = numClosids(RDT_ALLOCATION_SUBLEAF_L3)
 		}
 		// L2 Cache Allocation
 		if rdtAllocation.EBX&RDT_ALLOCATION_EBX_L2_CACHE_ALLOCATION != 0 {
-			features = append(features, "RDTL2CA")
+			features["RDTL2CA"] = true
+			features["RDTL2CA.closids"] = numClosids(RDT_ALLOCATION_SUBLEAF_L2)
 		}
 		// Memory Bandwidth Allocation
 		if rdtAllocation.EBX&RDT_ALLOCATION_EBX_MEMORY_BANDWIDTH_ALLOCATION != 0 {
-			features = append(features, "RDTMBA")
+			features["RDTMBA"] = true
+			features["RDTMBA.closids"] = numClosids(RDT_ALLOCATION_SUBLEAF_MB)
 		}
 	}
 
 	return features
 }
 
-func cpuid(eax, ecx uint32) CpuidRet {
-	r := CpuidRet{}
-	r.EAX, r.EBX, r.ECX, r.EDX = cpuidAsm(eax, ecx)
-	return r
+// Get the number of classes of service (CLOSIDs) of an allocation resource
+func numClosids(subleaf uint32) uint32 {
+	return cpuidutils.Cpuid(LEAF_RDT_ALLOCATION, subleaf).EDX&RDT_ALLOCATION_EDX_HIGHEST_COS_MASK + 1
 }
-
-func cpuidAsm(eax_arg, ecx_arg uint32) (eax, ebx, ecx, edx uint32)
@@ -18,6 +18,10 @@ limitations under the License.
 
 package rdt
 
-func discoverRDT() []string {
-	return []string{}
+import (
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+func discoverRDT() source.Features {
+	return source.Features{}
 }
@@ -0,0 +1,59 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/labelutils"
+)
+
+// NodeRuntimeVersion returns the container runtime version reported by the
+// kubelet in the status of the node, e.g. containerd://1.6.8. Set up by
+// nfd-worker, as the node object is fetched from the API server.
+var NodeRuntimeVersion func() (string, error)
+
+// Implement FeatureSource interface
+type Source struct{}
+
+func (s Source) Name() string { return "runtime" }
+
+func (s Source) Discover() (source.Features, error) {
+	features := source.Features{}
+
+	if NodeRuntimeVersion == nil {
+		return features, fmt.Errorf("node status not available")
+	}
+	version, err := NodeRuntimeVersion()
+	if err != nil {
+		return features, fmt.Errorf("failed to get the node status: %s", err)
+	}
+
+	// The version is of the form <runtime name>://<version>
+	split := strings.SplitN(version, "://", 2)
+	if len(split) != 2 || split[0] == "" {
+		return features, fmt.Errorf("invalid container runtime version %q", version)
+	}
+	features["name"] = labelutils.Value(split[0])
+	if v := labelutils.Value(split[1]); v != "" {
+		features["version"] = v
+	}
+
+	return features, nil
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package security
+
+import (
+	"io/ioutil"
+	"os"
+	"regexp"
+)
+
+// Paths of the lockdown state, in order of preference. The container's own
+// sysfs is used as a fallback if the host sysfs is not mounted.
+var lockdownPaths = []string{"/host-sys/kernel/security/lockdown", "/sys/kernel/security/lockdown"}
+
+// The active lockdown mode is enclosed in brackets, e.g.
+// "none [integrity] confidentiality"
+var lockdownModeRe = regexp.MustCompile(`\[(\w+)\]`)
+
+// Detect the kernel lockdown mode. The lockdown file only exists if the
+// kernel was built with the lockdown LSM and securityfs is mounted.
+func detectLockdown() (map[string]interface{}, error) {
+	lockdown := map[string]interface{}{}
+
+	for _, p := range lockdownPaths {
+		data, err := ioutil.ReadFile(p)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		m := lockdownModeRe.FindStringSubmatch(string(data))
+		if m == nil {
+			break
+		}
+		lockdown["mode"] = m[1]
+		if m[1] != "none" {
+			lockdown["enabled"] = true
+		}
+		break
+	}
+
+	return lockdown, nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package security
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source/internal/cpuinfoutils"
+)
+
+const hwRandomPath = "/sys/class/misc/hw_random"
+
+// CPU flags of random number generator instructions, as reported in
+// /proc/cpuinfo, mapped to feature names
+var rngFlags = map[string]string{
+	"rdrand": "rdrand",
+	"rdseed": "rdseed",
+	// Armv8.5 RNDR and RNDRRS instructions
+	"rng": "rndr",
+}
+
+// Detect hardware random number generators: RNG instructions of the CPU, and
+// a hardware RNG device, e.g. virtio-rng or TPM, registered with the
+// hw_random framework of the kernel
+func detectRng() (map[string]string, error) {
+	rng := map[string]string{}
+
+	flags, err := cpuinfoutils.Flags()
+	if err != nil {
+		return nil, err
+	}
+	for flag, name := range rngFlags {
+		if flags[flag] {
+			rng[name] = "true"
+		}
+	}
+
+	// The device exists even if no RNG is available, the current RNG
+	// being "none" then
+	current, err := ioutil.ReadFile(path.Join(hwRandomPath, "rng_current"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return rng, nil
+		}
+		return nil, err
+	}
+	if driver := strings.TrimSpace(string(current)); driver != "" && driver != "none" {
+		rng["hwrng"] = "true"
+		rng["driver"] = driver
+	}
+
+	return rng, nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package security
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// Seccomp mode of a process, as reported in /proc/<pid>/status
+const seccompModeFilter = "2"
+
+// Detect seccomp support of the kernel. Whether containers are confined by
+// default depends on the runtime and the pod spec, and can't be told from
+// the NFD pod.
+func detectSeccomp() (map[string]bool, error) {
+	seccomp := map[string]bool{}
+
+	// The Seccomp field is only present if the kernel was built with
+	// CONFIG_SECCOMP
+	mode := ""
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if strings.HasPrefix(s.Text(), "Seccomp:") {
+			mode = strings.TrimSpace(strings.TrimPrefix(s.Text(), "Seccomp:"))
+			break
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if mode == "" {
+		return seccomp, nil
+	}
+	seccomp["seccomp"] = true
+
+	// The seccomp sysctl directory only exists if the kernel was built with
+	// CONFIG_SECCOMP_FILTER, i.e. supports the BPF filters that runtime
+	// seccomp profiles are made of
+	if _, err := os.Stat("/proc/sys/kernel/seccomp/actions_avail"); err == nil || mode == seccompModeFilter {
+		seccomp["seccomp.filter"] = true
+	}
+
+	return seccomp, nil
+}
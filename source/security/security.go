@@ -0,0 +1,84 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package security
+
+import (
+	"log"
+	"os"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+var logger = log.New(os.Stderr, "", log.LstdFlags)
+
+// Implement FeatureSource interface
+type Source struct{}
+
+func (s Source) Name() string { return "security" }
+
+func (s Source) Discover() (source.Features, error) {
+	features := source.Features{}
+
+	// Detect TPM
+	tpm, err := detectTpm()
+	if err != nil {
+		logger.Printf("ERROR: failed to detect TPM: %s", err)
+	} else {
+		for k, v := range tpm {
+			features["tpm."+k] = v
+		}
+	}
+
+	// Detect hardware random number generators
+	rng, err := detectRng()
+	if err != nil {
+		logger.Printf("ERROR: failed to detect hardware RNG: %s", err)
+	} else {
+		for k, v := range rng {
+			features["rng."+k] = v
+		}
+	}
+
+	// Detect UEFI and Secure Boot
+	uefi, err := detectUefi()
+	if err != nil {
+		logger.Printf("ERROR: failed to detect Secure Boot: %s", err)
+	}
+	for k, v := range uefi {
+		features["uefi."+k] = v
+	}
+
+	// Detect seccomp
+	seccomp, err := detectSeccomp()
+	if err != nil {
+		logger.Printf("ERROR: failed to detect seccomp: %s", err)
+	}
+	for k, v := range seccomp {
+		features[k] = v
+	}
+
+	// Detect kernel lockdown
+	lockdown, err := detectLockdown()
+	if err != nil {
+		logger.Printf("ERROR: failed to detect kernel lockdown: %s", err)
+	}
+	for k, v := range lockdown {
+		features["lockdown."+k] = v
+	}
+
+	return features, nil
+}
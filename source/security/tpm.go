@@ -0,0 +1,79 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package security
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const tpmClassPath = "/sys/class/tpm"
+
+// Detect the presence, version and interface of a TPM device
+func detectTpm() (map[string]string, error) {
+	tpm := map[string]string{}
+
+	devices, err := ioutil.ReadDir(tpmClassPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return tpm, nil
+		}
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return tpm, nil
+	}
+	devPath := path.Join(tpmClassPath, devices[0].Name())
+
+	tpm["present"] = "true"
+	if version := tpmVersion(devPath); version != "" {
+		tpm["version"] = version
+	}
+
+	// Interface is determined by the driver, e.g. tpm_tis or tpm_crb
+	if driver, err := filepath.EvalSymlinks(path.Join(devPath, "device", "driver")); err == nil {
+		tpm["interface"] = strings.TrimPrefix(path.Base(driver), "tpm_")
+	}
+
+	return tpm, nil
+}
+
+// Determine the TPM specification version, "1.2" or "2.0"
+func tpmVersion(devPath string) string {
+	// Available in Linux v5.6 and later
+	if major, err := ioutil.ReadFile(path.Join(devPath, "tpm_version_major")); err == nil {
+		switch strings.TrimSpace(string(major)) {
+		case "1":
+			return "1.2"
+		case "2":
+			return "2.0"
+		}
+	}
+
+	// The in-kernel resource manager (/dev/tpmrm*) only exists for TPM 2.0
+	if _, err := os.Stat(path.Join("/sys/class/tpmrm", "tpmrm"+strings.TrimPrefix(path.Base(devPath), "tpm"))); err == nil {
+		return "2.0"
+	}
+	// Capabilities are only exported for TPM 1.2 devices
+	if _, err := os.Stat(path.Join(devPath, "device", "caps")); err == nil {
+		return "1.2"
+	}
+	return ""
+}
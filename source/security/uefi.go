@@ -0,0 +1,105 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package security
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+// GUID of the EFI global variables
+const efiGlobalVariableGuid = "8be4df61-93ca-11d2-aa0d-00e098032b8c"
+
+// Paths to search for EFI information, in order of preference. The
+// container's own sysfs is used as a fallback if the host sysfs is not
+// mounted. It lacks efivarfs, but EFI boot can still be detected.
+var efiPaths = []string{"/host-sys/firmware/efi", "/sys/firmware/efi"}
+
+// The mounts of the NFD pod don't change while it runs
+var efiFallbackLog sync.Once
+
+// Detect UEFI boot and the state of Secure Boot
+func detectUefi() (source.Features, error) {
+	uefi := source.Features{}
+
+	// The efi directory only exists if the system was booted via UEFI
+	efiPath := ""
+	for i, p := range efiPaths {
+		if _, err := os.Stat(p); err == nil {
+			if i > 0 {
+				efiFallbackLog.Do(func() {
+					logger.Printf("host sysfs not available, detecting UEFI from %s", p)
+				})
+				uefi["source"] = "container"
+			}
+			efiPath = p
+			break
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	if efiPath == "" {
+		return uefi, nil
+	}
+	uefi["enabled"] = true
+
+	secureBoot, err := readEfiBoolVar(efiPath, "SecureBoot")
+	if err != nil {
+		return uefi, err
+	}
+	setupMode, err := readEfiBoolVar(efiPath, "SetupMode")
+	if err != nil {
+		return uefi, err
+	}
+
+	// Secure Boot is not enforced while in setup mode
+	if secureBoot && !setupMode {
+		uefi["secureboot"] = true
+	}
+	if setupMode {
+		uefi["setupmode"] = true
+	}
+
+	return uefi, nil
+}
+
+// Read a one-byte boolean EFI global variable
+func readEfiBoolVar(efiPath, name string) (bool, error) {
+	varName := name + "-" + efiGlobalVariableGuid
+
+	// Try efivarfs first, where the data is preceded by four bytes of
+	// variable attributes
+	data, err := ioutil.ReadFile(path.Join(efiPath, "efivars", varName))
+	if err == nil && len(data) > 4 {
+		return data[4] == 1, nil
+	}
+
+	// Fall back to the deprecated sysfs interface
+	data, err = ioutil.ReadFile(path.Join(efiPath, "vars", varName, "data"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read EFI variable %s: %s", name, err)
+	}
+	return len(data) > 0 && data[0] == 1, nil
+}
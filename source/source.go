@@ -40,3 +40,42 @@ type FeatureSource interface {
 	// Discover returns discovered features for this node.
 	Discover() (Features, error)
 }
+
+// NetworkFeatureSource is implemented by feature sources that need to access
+// the network, e.g. remote metadata services, for discovery. Sources that
+// only inspect the local node must not implement it.
+type NetworkFeatureSource interface {
+	FeatureSource
+
+	// UsesNetwork returns true if the source accesses the network.
+	UsesNetwork() bool
+}
+
+// Driver describes the driver, and the firmware, of a device.
+type Driver struct {
+	Device   string `json:"device"`
+	Driver   string `json:"driver"`
+	Version  string `json:"version,omitempty"`
+	Firmware string `json:"firmware,omitempty"`
+}
+
+// DriverSource is implemented by feature sources that are able to report the
+// drivers and firmware of the devices they discover, for the driver manifest
+// of the node.
+type DriverSource interface {
+	FeatureSource
+
+	// Drivers returns the drivers of the devices discovered by this source.
+	Drivers() ([]Driver, error)
+}
+
+// PrioritySource is implemented by feature sources that need to be ordered
+// relative to the other sources they are run with. Sources without a priority
+// have priority 0. Sources with a higher priority value are ordered later,
+// i.e. their labels override the labels of sources ordered earlier.
+type PrioritySource interface {
+	FeatureSource
+
+	// Priority returns the priority of the source.
+	Priority() int
+}
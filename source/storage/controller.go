@@ -0,0 +1,95 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+const sysfsPciDevices = "/sys/bus/pci/devices/"
+
+// PCI classes of storage controllers
+const (
+	pciClassRaid = "0104"
+	pciClassSas  = "0107"
+)
+
+// Drivers of chipset SATA controllers in RAID mode, i.e. firmware or
+// software RAID, which are not hardware RAID controllers
+var softRaidDrivers = map[string]bool{
+	"ahci": true,
+	"vmd":  true,
+}
+
+// discoverControllers detects hardware RAID controllers and SAS host bus
+// adapters (HBAs). The model of the first RAID controller is reported as its
+// PCI vendor and device IDs, e.g. "1000_0016".
+func discoverControllers() (source.Features, error) {
+	features := source.Features{}
+
+	devices, err := ioutil.ReadDir(sysfsPciDevices)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return features, nil
+		}
+		return nil, err
+	}
+
+	raid, hba := 0, 0
+	for _, device := range devices {
+		devPath := sysfsPciDevices + device.Name()
+		class := strings.TrimPrefix(readTrimmed(devPath+"/class"), "0x")
+		if len(class) > 4 {
+			class = class[:4]
+		}
+		driver := ""
+		if driverPath, err := filepath.EvalSymlinks(devPath + "/driver"); err == nil {
+			driver = filepath.Base(driverPath)
+		}
+
+		switch {
+		case class == pciClassRaid && !softRaidDrivers[driver]:
+			if raid == 0 {
+				vendor := strings.TrimPrefix(readTrimmed(devPath+"/vendor"), "0x")
+				dev := strings.TrimPrefix(readTrimmed(devPath+"/device"), "0x")
+				features["raid.model"] = vendor + "_" + dev
+				if driver != "" {
+					features["raid.driver"] = driver
+				}
+			}
+			raid++
+		case class == pciClassSas:
+			hba++
+		}
+	}
+
+	if raid > 0 {
+		features["raid.present"] = true
+		features["raid.count"] = raid
+	}
+	if hba > 0 {
+		features["hba.present"] = true
+		features["hba.count"] = hba
+	}
+
+	return features, nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+const fcHostPath = "/sys/class/fc_host/"
+
+// discoverFc detects Fibre Channel HBA ports: the number of ports, i.e.
+// port WWNs, the number of ports connected to a fabric, and the highest
+// speed of the connected ports in Gbit/s
+func discoverFc() (source.Features, error) {
+	features := source.Features{}
+
+	hosts, err := ioutil.ReadDir(fcHostPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return features, nil
+		}
+		return nil, err
+	}
+	if len(hosts) == 0 {
+		return features, nil
+	}
+
+	wwns := map[string]bool{}
+	online, speed := 0, 0
+	for _, host := range hosts {
+		hostPath := fcHostPath + host.Name()
+		if wwn := readTrimmed(hostPath + "/port_name"); wwn != "" {
+			wwns[wwn] = true
+		}
+		if readTrimmed(hostPath+"/port_state") != "Online" {
+			continue
+		}
+		online++
+		// E.g. "16 Gbit", or "unknown"
+		fields := strings.Fields(readTrimmed(hostPath + "/speed"))
+		if len(fields) == 2 && fields[1] == "Gbit" {
+			if s, err := strconv.Atoi(fields[0]); err == nil && s > speed {
+				speed = s
+			}
+		}
+	}
+
+	features["fc.present"] = true
+	features["fc.ports"] = len(hosts)
+	features["fc.wwns"] = len(wwns)
+	features["fc.online"] = online
+	if speed > 0 {
+		features["fc.speed"] = speed
+	}
+
+	return features, nil
+}
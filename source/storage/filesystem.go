@@ -0,0 +1,113 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+// Filesystems to detect, mapped to the name of the kernel module providing
+// them
+var filesystems = map[string]string{
+	"btrfs":   "btrfs",
+	"ext4":    "ext4",
+	"overlay": "overlay",
+	"xfs":     "xfs",
+	"zfs":     "zfs",
+}
+
+// discoverFilesystems detects the filesystems supported by the kernel. A
+// filesystem is supported if it is registered in the kernel, i.e. built-in or
+// its module is loaded, or if its module is available for loading.
+func discoverFilesystems() (source.Features, error) {
+	features := source.Features{}
+
+	registered, err := registeredFilesystems()
+	if err != nil {
+		return nil, err
+	}
+	modules := availableModules()
+
+	for fs, module := range filesystems {
+		if registered[fs] || modules[module] {
+			features["filesystem."+fs] = true
+		}
+	}
+
+	return features, nil
+}
+
+// registeredFilesystems returns the filesystems listed in /proc/filesystems
+func registeredFilesystems() (map[string]bool, error) {
+	f, err := os.Open("/proc/filesystems")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	registered := map[string]bool{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Lines are of the form "[nodev]\t<name>"
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 0 {
+			registered[fields[len(fields)-1]] = true
+		}
+	}
+	return registered, scanner.Err()
+}
+
+// availableModules returns the names of the kernel modules installed for the
+// running kernel, read from modules.dep of the host
+func availableModules() map[string]bool {
+	modules := map[string]bool{}
+
+	release, err := ioutil.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return modules
+	}
+
+	var raw []byte
+	for _, dir := range []string{"/host-lib/modules/", "/lib/modules/"} {
+		raw, err = ioutil.ReadFile(dir + strings.TrimSpace(string(release)) + "/modules.dep")
+		if err == nil {
+			break
+		}
+	}
+	if raw == nil {
+		return modules
+	}
+
+	// Lines are of the form "<path>/<module>.ko[.<compression>]: <deps>"
+	for _, line := range strings.Split(string(raw), "\n") {
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		name := path.Base(line[:i])
+		if j := strings.Index(name, ".ko"); j > 0 {
+			modules[strings.Replace(name[:j], "-", "_", -1)] = true
+		}
+	}
+	return modules
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"os"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+// discoverInitiators detects kernel support for iSCSI and NVMe over Fabrics
+// initiators, and whether the initiator tooling has been set up on the host.
+func discoverInitiators() source.Features {
+	features := source.Features{}
+
+	if exists("/sys/module/iscsi_tcp") {
+		features["iscsi.kernel"] = true
+	}
+	// The initiator name is generated when open-iscsi is installed
+	if exists("/host-etc/iscsi/initiatorname.iscsi") {
+		features["iscsi.initiator"] = true
+	}
+
+	if exists("/sys/module/nvme_tcp") {
+		features["nvmeof.tcp"] = true
+	}
+	if exists("/sys/module/nvme_rdma") {
+		features["nvmeof.rdma"] = true
+	}
+	// The host NQN is generated when nvme-cli is installed
+	if exists("/host-etc/nvme/hostnqn") {
+		features["nvmeof.initiator"] = true
+	}
+
+	return features
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
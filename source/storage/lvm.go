@@ -0,0 +1,160 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"bufio"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/labelutils"
+)
+
+// LVM keeps a backup of the metadata of each volume group, updated on every
+// change, which can be read without LVM tools or access to the disks
+const lvmBackupDir = "/host-etc/lvm/backup"
+
+// Maximum length of a volume group name, so that the label name, i.e.
+// storage-lvm.vg.<name>, does not exceed 63 characters
+const maxVgNameLen = 63 - len("storage-lvm.vg.")
+
+// discoverLvm detects device-mapper support and the LVM volume groups of the
+// host, with their free capacity.
+func discoverLvm() (source.Features, error) {
+	features := source.Features{}
+
+	// The device-mapper block device major is registered when dm_mod is
+	// loaded or built into the kernel
+	devices, err := ioutil.ReadFile("/proc/devices")
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(devices), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[1] == "device-mapper" {
+			features["devicemapper.enabled"] = true
+			break
+		}
+	}
+	if exists("/sys/module/dm_thin_pool") {
+		features["devicemapper.thin"] = true
+	}
+
+	// Failures to read the volume groups do not affect the device-mapper
+	// features, nor the other volume groups
+	files, err := ioutil.ReadDir(lvmBackupDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("ERROR: failed to read LVM metadata backups: %s", err)
+		}
+		return features, nil
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		vg, free, err := parseLvmBackup(filepath.Join(lvmBackupDir, f.Name()))
+		if err != nil {
+			log.Printf("ERROR: failed to parse LVM metadata backup %s: %s", f.Name(), err)
+			continue
+		}
+		if vg == "" {
+			continue
+		}
+		// VG names may contain "+", which is not valid in label names
+		name := labelutils.Value(vg)
+		if name == "" || len(name) > maxVgNameLen {
+			log.Printf("WARNING: volume group name %q is not usable in a label name, ignoring...", vg)
+			continue
+		}
+		features["lvm.vg."+name] = labelutils.FormatKiB(free / 1024)
+	}
+
+	return features, nil
+}
+
+// parseLvmBackup parses an LVM metadata backup file, and returns the name of
+// the volume group and its free capacity in bytes. Only segments of type
+// "striped", i.e. linear and striped logical volumes and the sub-volumes of
+// RAID, mirrored and thin pool volumes, occupy extents of physical volumes.
+func parseLvmBackup(path string) (string, uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	vg := ""
+	var extentSize, extents, used uint64
+	var segType string
+	var segExtents uint64
+	sections := []string{}
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case strings.HasSuffix(line, "{"):
+			name := strings.TrimSpace(strings.TrimSuffix(line, "{"))
+			if len(sections) == 0 {
+				vg = name
+			}
+			sections = append(sections, name)
+			segType, segExtents = "", 0
+		case line == "}":
+			// End of a segment of a logical volume
+			if len(sections) == 4 && sections[1] == "logical_volumes" && segType == "striped" {
+				used += segExtents
+			}
+			if len(sections) > 0 {
+				sections = sections[:len(sections)-1]
+			}
+		case strings.Contains(line, "="):
+			kv := strings.SplitN(line, "=", 2)
+			key, value := strings.TrimSpace(kv[0]), strings.Trim(strings.TrimSpace(kv[1]), `"`)
+			n, _ := strconv.ParseUint(value, 10, 64)
+			switch {
+			case len(sections) == 1 && key == "extent_size":
+				extentSize = n
+			case len(sections) == 3 && sections[1] == "physical_volumes" && key == "pe_count":
+				extents += n
+			case len(sections) == 4 && sections[1] == "logical_volumes" && key == "type":
+				segType = value
+			case len(sections) == 4 && sections[1] == "logical_volumes" && key == "extent_count":
+				segExtents = n
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return "", 0, err
+	}
+
+	if used > extents {
+		used = extents
+	}
+	// The extent size is given in 512-byte sectors
+	return vg, (extents - used) * extentSize * 512, nil
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"regexp"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+// Block devices of NVMe namespaces, excluding the hidden per-path devices of
+// multipath namespaces (nvme<subsys>c<ctrl>n<ns>)
+var nvmeNamespaceRe = regexp.MustCompile(`^nvme[0-9]+n[0-9]+$`)
+
+// discoverNvme returns the number of NVMe controllers and namespaces
+func discoverNvme() (source.Features, error) {
+	features := source.Features{}
+
+	ctrls, err := ioutil.ReadDir("/sys/class/nvme/")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return features, nil
+		}
+		return nil, err
+	}
+	if len(ctrls) == 0 {
+		return features, nil
+	}
+	features["nvme.present"] = true
+	features["nvme.controllers"] = len(ctrls)
+
+	blockdevices, err := ioutil.ReadDir("/sys/block/")
+	if err != nil {
+		return nil, err
+	}
+	namespaces := 0
+	for _, bdev := range blockdevices {
+		if nvmeNamespaceRe.MatchString(bdev.Name()) {
+			namespaces++
+		}
+	}
+	features["nvme.namespaces"] = namespaces
+
+	return features, nil
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+// mountDisk returns the name of the disk, e.g. sda, backing the filesystem
+// mounted at the given mount point. In a container the root filesystem is
+// usually not backed by a block device, while the /etc/hosts file is
+// bind-mounted from the pod directory of kubelet on the host.
+func mountDisk(mountPoint string) (string, error) {
+	dev, err := mountDevice(mountPoint)
+	if err != nil {
+		return "", err
+	}
+	sysPath, err := filepath.EvalSymlinks("/sys/dev/block/" + dev)
+	if err != nil {
+		// Not a block device, e.g. overlayfs
+		return "", fmt.Errorf("no block device found for %s", mountPoint)
+	}
+	return baseDisk(sysPath), nil
+}
+
+// mountDevice returns the device number (<major>:<minor>) of the filesystem
+// mounted at the given mount point
+func mountDevice(mountPoint string) (string, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	dev := ""
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// Line is of the form
+		// "<id> <parent id> <major>:<minor> <root> <mount point> ..."
+		fields := strings.Fields(s.Text())
+		// Later mounts shadow earlier ones
+		if len(fields) >= 5 && fields[4] == mountPoint {
+			dev = fields[2]
+		}
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	if dev == "" {
+		return "", fmt.Errorf("%s is not a mount point", mountPoint)
+	}
+	return dev, nil
+}
+
+// addDiskRotational adds the <name>.nonrotational feature of a disk
+func addDiskRotational(features source.Features, name, disk string) {
+	if bytes, err := ioutil.ReadFile("/sys/block/" + disk + "/queue/rotational"); err == nil {
+		features[name+".nonrotational"] = bytes[0] == byte('0')
+	}
+}
+
+// baseDisk returns the name of the disk underlying a block device, given by
+// its sysfs path. Partitions are resolved to the disk they are on, and
+// device-mapper and software RAID devices to the first of their components.
+func baseDisk(sysPath string) string {
+	for i := 0; i < 8; i++ {
+		if _, err := os.Stat(sysPath + "/partition"); err == nil {
+			sysPath = filepath.Dir(sysPath)
+			continue
+		}
+		slaves, err := ioutil.ReadDir(sysPath + "/slaves")
+		if err != nil || len(slaves) == 0 {
+			break
+		}
+		next, err := filepath.EvalSymlinks(sysPath + "/slaves/" + slaves[0].Name())
+		if err != nil {
+			break
+		}
+		sysPath = next
+	}
+	return filepath.Base(sysPath)
+}
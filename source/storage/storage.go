@@ -19,6 +19,10 @@ package storage
 import (
 	"fmt"
 	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"sigs.k8s.io/node-feature-discovery/source"
 )
@@ -29,13 +33,18 @@ type Source struct{}
 // Name returns an identifier string for this feature source.
 func (s Source) Name() string { return "storage" }
 
-// Discover returns feature names for storage: nonrotationaldisk if any SSD drive present.
+// Discover returns feature names for storage: nonrotationaldisk if any SSD drive present,
+// the number of rotational and non-rotational disks, whether the root disk
+// (or the disk of the kubelet pod directory) is non-rotational, iSCSI and
+// NVMe-oF initiator support, hardware RAID controllers, SAS and Fibre Channel HBAs, the NVMe controllers and namespaces, device-mapper
+// support and the free capacity of LVM volume groups.
 func (s Source) Discover() (source.Features, error) {
 	features := source.Features{}
 
 	// Check if there is any non-rotational block devices attached to the node
 	blockdevices, err := ioutil.ReadDir("/sys/block/")
 	if err == nil {
+		rotational, nonRotational := 0, 0
 		for _, bdev := range blockdevices {
 			fname := "/sys/block/" + bdev.Name() + "/queue/rotational"
 			bytes, err := ioutil.ReadFile(fname)
@@ -45,9 +54,140 @@ func (s Source) Discover() (source.Features, error) {
 			if bytes[0] == byte('0') {
 				// Non-rotational storage is present, add label.
 				features["nonrotationaldisk"] = true
-				break
+			}
+			// Only count physical disks, virtual block devices like
+			// loop devices have no device
+			if _, err := os.Stat("/sys/block/" + bdev.Name() + "/device"); err != nil {
+				continue
+			}
+			if bytes[0] == byte('0') {
+				nonRotational++
+			} else {
+				rotational++
 			}
 		}
+		if nonRotational > 0 {
+			features["nonrotationaldisk.count"] = nonRotational
+		}
+		if rotational > 0 {
+			features["rotationaldisk.count"] = rotational
+		}
+	}
+
+	// The disk of the kubelet pod directory is published separately, as it
+	// is not necessarily the one holding the root filesystem of the host
+	if disk, err := mountDisk("/"); err == nil {
+		addDiskRotational(features, "rootdisk", disk)
+	} else if disk, err := mountDisk("/etc/hosts"); err == nil {
+		addDiskRotational(features, "kubeletdisk", disk)
+	} else {
+		log.Printf("WARNING: can't determine root or kubelet disk: %s", err.Error())
+	}
+
+	for k, v := range discoverInitiators() {
+		features[k] = v
+	}
+
+	fs, err := discoverFilesystems()
+	if err != nil {
+		log.Printf("WARNING: can't detect supported filesystems: %s", err.Error())
+	}
+	for k, v := range fs {
+		features[k] = v
+	}
+
+	ctrls, err := discoverControllers()
+	if err != nil {
+		log.Printf("WARNING: can't detect storage controllers: %s", err.Error())
+	}
+	for k, v := range ctrls {
+		features[k] = v
+	}
+
+	fc, err := discoverFc()
+	if err != nil {
+		log.Printf("WARNING: can't detect Fibre Channel HBAs: %s", err.Error())
+	}
+	for k, v := range fc {
+		features[k] = v
+	}
+
+	nvme, err := discoverNvme()
+	if err != nil {
+		return nil, fmt.Errorf("can't detect NVMe devices: %s", err.Error())
+	}
+	for k, v := range nvme {
+		features[k] = v
+	}
+
+	lvm, err := discoverLvm()
+	if err != nil {
+		log.Printf("WARNING: can't detect LVM volume groups: %s", err.Error())
+	}
+	for k, v := range lvm {
+		features[k] = v
 	}
+
 	return features, nil
 }
+
+// Drivers returns the drivers of the PCI storage controllers of the block
+// devices, and the firmware of NVMe controllers.
+func (s Source) Drivers() ([]source.Driver, error) {
+	blockdevices, err := ioutil.ReadDir("/sys/block/")
+	if err != nil {
+		return nil, fmt.Errorf("can't list block devices: %s", err.Error())
+	}
+
+	drivers := []source.Driver{}
+	seen := map[string]bool{}
+	for _, bdev := range blockdevices {
+		// Virtual block devices, e.g. loop devices, have no device
+		devPath, err := filepath.EvalSymlinks("/sys/block/" + bdev.Name() + "/device")
+		if err != nil {
+			continue
+		}
+		ctrlPath := pciController(devPath)
+		if ctrlPath == "" || seen[ctrlPath] {
+			continue
+		}
+		seen[ctrlPath] = true
+
+		driverPath, err := filepath.EvalSymlinks(ctrlPath + "/driver")
+		if err != nil {
+			continue
+		}
+		driver := source.Driver{
+			Device: filepath.Base(ctrlPath),
+			Driver: filepath.Base(driverPath),
+		}
+		if module, err := os.Readlink(driverPath + "/module"); err == nil {
+			driver.Version = readTrimmed("/sys/module/" + filepath.Base(module) + "/version")
+		}
+		driver.Firmware = readTrimmed(devPath + "/firmware_rev")
+		drivers = append(drivers, driver)
+	}
+	return drivers, nil
+}
+
+// pciController returns the sysfs path of the closest PCI device in the
+// device hierarchy of a block device
+func pciController(devPath string) string {
+	for p := devPath; p != "/" && p != "."; p = filepath.Dir(p) {
+		subsystem, err := os.Readlink(p + "/subsystem")
+		if err == nil && filepath.Base(subsystem) == "pci" {
+			return p
+		}
+	}
+	return ""
+}
+
+// readTrimmed returns the content of a sysfs file, or an empty string if the
+// file cannot be read
+func readTrimmed(path string) string {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
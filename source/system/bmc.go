@@ -0,0 +1,66 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package system
+
+import (
+	"path/filepath"
+)
+
+// SMBIOS structures, exposed by the kernel in /sys/firmware/dmi/entries as
+// <type>-<instance>
+const (
+	dmiEntriesPath = "/sys/firmware/dmi/entries"
+	// IPMI Device Information
+	dmiTypeIpmi = "38"
+	// Management Controller Host Interface, i.e. Redfish over the host
+	// interface
+	dmiTypeHostInterface = "42"
+)
+
+// Class of the IPMI device interface, with an ipmi<N> device per interface
+// registered by the IPMI driver. Device nodes of the host are not available
+// in the NFD pod, /dev being that of the container.
+const ipmiClassPath = "/sys/class/ipmi"
+
+// Detect a baseboard management controller (BMC), from the SMBIOS tables and
+// the IPMI driver
+func detectBmc() map[string]string {
+	bmc := map[string]string{}
+
+	ipmiDevices, _ := filepath.Glob(filepath.Join(ipmiClassPath, "ipmi*"))
+	ipmiReady := len(ipmiDevices) > 0
+	if ipmiReady || dmiEntryExists(dmiTypeIpmi) {
+		bmc["ipmi"] = "true"
+	}
+	if ipmiReady {
+		bmc["ipmi.driver_ready"] = "true"
+	}
+	if dmiEntryExists(dmiTypeHostInterface) {
+		bmc["redfish"] = "true"
+	}
+	if len(bmc) > 0 {
+		bmc["present"] = "true"
+	}
+
+	return bmc
+}
+
+// Check if the SMBIOS tables contain a structure of the given type
+func dmiEntryExists(dmiType string) bool {
+	entries, _ := filepath.Glob(filepath.Join(dmiEntriesPath, dmiType+"-*"))
+	return len(entries) > 0
+}
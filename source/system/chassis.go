@@ -0,0 +1,136 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package system
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source/internal/cpuinfoutils"
+)
+
+// Device tree of the platform, on e.g. Arm boards
+const deviceTreePath = "/sys/firmware/devicetree/base"
+
+// Chassis types of the SMBIOS specification, by category
+var dmiChassisTypes = map[int]string{
+	3:  "desktop",  // Desktop
+	4:  "desktop",  // Low Profile Desktop
+	5:  "desktop",  // Pizza Box
+	6:  "desktop",  // Mini Tower
+	7:  "desktop",  // Tower
+	8:  "laptop",   // Portable
+	9:  "laptop",   // Laptop
+	10: "laptop",   // Notebook
+	11: "handheld", // Hand Held
+	13: "desktop",  // All in One
+	14: "laptop",   // Sub Notebook
+	15: "desktop",  // Space-saving
+	16: "desktop",  // Lunch Box
+	17: "server",   // Main Server Chassis
+	23: "rack",     // Rack Mount Chassis
+	24: "desktop",  // Sealed-case PC
+	25: "server",   // Multi-system chassis
+	26: "embedded", // Compact PCI
+	27: "embedded", // AdvancedTCA
+	28: "blade",    // Blade
+	29: "blade",    // Blade Enclosure
+	30: "tablet",   // Tablet
+	31: "laptop",   // Convertible
+	32: "laptop",   // Detachable
+	33: "embedded", // IoT Gateway
+	34: "embedded", // Embedded PC
+	35: "desktop",  // Mini PC
+	36: "desktop",  // Stick PC
+}
+
+// Chassis types of the device tree specification, by category
+var deviceTreeChassisTypes = map[string]string{
+	"desktop":     "desktop",
+	"laptop":      "laptop",
+	"convertible": "laptop",
+	"server":      "server",
+	"tablet":      "tablet",
+	"handset":     "handheld",
+	"watch":       "handheld",
+	"embedded":    "embedded",
+}
+
+// Detect the type of the chassis: "vm" for virtual machines, "rack",
+// "blade" or "server" for servers, "desktop", "laptop", "tablet",
+// "handheld", or "embedded" for e.g. gateways and single board computers
+func chassisType() string {
+	if isVirtualMachine() {
+		return "vm"
+	}
+
+	if data, err := ioutil.ReadFile(path.Join(dmiPath, "chassis_type")); err == nil {
+		if t, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+			// Other and unknown types, for example, are not
+			// categorized
+			return dmiChassisTypes[t]
+		}
+	}
+
+	if data, err := ioutil.ReadFile(path.Join(deviceTreePath, "chassis-type")); err == nil {
+		if t, ok := deviceTreeChassisTypes[strings.TrimRight(string(data), "\x00\n")]; ok {
+			return t
+		}
+	}
+	// Boards described by a device tree, but without a chassis type, are
+	// most likely single board computers
+	if _, err := os.Stat(path.Join(deviceTreePath, "compatible")); err == nil {
+		if _, err := os.Stat(dmiPath); os.IsNotExist(err) {
+			return "embedded"
+		}
+	}
+
+	return ""
+}
+
+// Xen feature flag set in the control domain, see
+// include/xen/interface/features.h in the kernel sources
+const xenFeatDom0 = 11
+
+// Check if running in a virtual machine, as reported by the CPU (x86) or
+// the kernel (e.g. Xen PV guests)
+func isVirtualMachine() bool {
+	if data, err := ioutil.ReadFile("/sys/hypervisor/type"); err == nil {
+		// The Xen control domain (dom0) also reports a hypervisor, but
+		// owns the hardware, so it isn't considered a virtual machine
+		if strings.TrimSpace(string(data)) == "xen" && isXenDom0() {
+			return false
+		}
+		return true
+	}
+
+	flags, err := cpuinfoutils.Flags()
+	return err == nil && flags["hypervisor"]
+}
+
+// Check if running in the Xen control domain
+func isXenDom0() bool {
+	data, err := ioutil.ReadFile("/sys/hypervisor/properties/features")
+	if err != nil {
+		return false
+	}
+	features, err := strconv.ParseUint(strings.TrimSpace(string(data)), 16, 64)
+	return err == nil && features&(1<<xenFeatDom0) != 0
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package system
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source/internal/labelutils"
+)
+
+const dmiPath = "/sys/class/dmi/id"
+
+// DMI/SMBIOS fields published as features. Serial numbers and UUIDs are
+// only readable by root, and not useful for scheduling.
+var dmiFields = []string{
+	"sys_vendor",
+	"product_name",
+	"product_version",
+	"board_vendor",
+	"board_name",
+	"bios_vendor",
+	"bios_version",
+}
+
+// Placeholders left by firmware vendors in unused DMI fields, in lowercase
+var dmiPlaceholders = map[string]bool{
+	"default string":         true,
+	"not applicable":         true,
+	"not specified":          true,
+	"none":                   true,
+	"system product name":    true,
+	"system version":         true,
+	"to be filled by o.e.m.": true,
+}
+
+// Read the DMI/SMBIOS identification of the system, the values turned into
+// valid label values, e.g. "Dell Inc." into "Dell-Inc"
+func readDmi() (map[string]string, error) {
+	dmi := map[string]string{}
+
+	for _, field := range dmiFields {
+		data, err := ioutil.ReadFile(path.Join(dmiPath, field))
+		if err != nil {
+			if os.IsNotExist(err) {
+				// DMI is not available on all platforms, e.g.
+				// on most Arm boards
+				continue
+			}
+			return nil, err
+		}
+		value := strings.TrimSpace(string(data))
+		if value == "" || dmiPlaceholders[strings.ToLower(value)] {
+			continue
+		}
+		if value = labelutils.Value(value); value != "" {
+			dmi[field] = value
+		}
+	}
+
+	return dmi, nil
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package system
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const powerSupplyClassPath = "/sys/class/power_supply"
+
+// Detect the power supplies of the node: whether it has a battery or a UPS
+// reported by the kernel, and whether it is currently running on mains
+// (AC or DC adapter) power
+func detectPowerSupply() (map[string]string, error) {
+	power := map[string]string{}
+
+	supplies, err := ioutil.ReadDir(powerSupplyClassPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return power, nil
+		}
+		return nil, err
+	}
+
+	mains, online := false, false
+	for _, supply := range supplies {
+		supplyPath := path.Join(powerSupplyClassPath, supply.Name())
+		// Batteries of peripherals, e.g. wireless mice, do not power
+		// the system
+		if readTrimmed(path.Join(supplyPath, "scope")) == "Device" {
+			continue
+		}
+		switch readTrimmed(path.Join(supplyPath, "type")) {
+		case "Battery":
+			if readTrimmed(path.Join(supplyPath, "present")) != "0" {
+				power["battery"] = "true"
+			}
+		case "UPS":
+			power["ups"] = "true"
+		case "Mains":
+			mains = true
+			if readTrimmed(path.Join(supplyPath, "online")) == "1" {
+				online = true
+			}
+		}
+	}
+	if mains {
+		power["ac_online"] = strconv.FormatBool(online)
+	}
+
+	return power, nil
+}
+
+// Read a sysfs attribute, returning an empty string if it cannot be read
+func readTrimmed(file string) string {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -57,17 +57,68 @@ func (s Source) Discover() (source.Features, error) {
 			}
 		}
 	}
+
+	dmi, err := readDmi()
+	if err != nil {
+		log.Printf("ERROR: failed to read DMI: %s", err)
+	}
+	for k, v := range dmi {
+		features["dmi."+k] = v
+	}
+
+	if chassis := chassisType(); chassis != "" {
+		features["chassis.type"] = chassis
+	}
+
+	for k, v := range detectBmc() {
+		features["bmc."+k] = v
+	}
+
+	thunderbolt, err := detectThunderbolt()
+	if err != nil {
+		log.Printf("ERROR: failed to detect Thunderbolt controllers: %s", err)
+	}
+	for k, v := range thunderbolt {
+		features["thunderbolt."+k] = v
+	}
+
+	watchdog, err := detectWatchdog()
+	if err != nil {
+		log.Printf("ERROR: failed to detect watchdog devices: %s", err)
+	}
+	for k, v := range watchdog {
+		features["watchdog."+k] = v
+	}
+
+	power, err := detectPowerSupply()
+	if err != nil {
+		log.Printf("ERROR: failed to detect power supplies: %s", err)
+	}
+	for k, v := range power {
+		features["power."+k] = v
+	}
+
 	return features, nil
 }
 
-// Read and parse os-release file
+// Read and parse os-release file. The file is mounted separately from the
+// rest of the host /etc, as it is commonly a symlink into /usr/lib, which
+// can't be followed within the /etc mount.
 func parseOSRelease() (map[string]string, error) {
 	release := map[string]string{}
 
-	f, err := os.Open("/host-etc/os-release")
+	var f *os.File
+	var err error
+	for _, path := range []string{"/host-os-release", "/host-etc/os-release"} {
+		f, err = os.Open(path)
+		if err == nil {
+			break
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
+	defer f.Close()
 
 	re := regexp.MustCompile(`^(?P<key>\w+)=(?P<value>.+)`)
 
@@ -80,7 +131,7 @@ func parseOSRelease() (map[string]string, error) {
 		}
 	}
 
-	return release, nil
+	return release, s.Err()
 }
 
 // Split version number into sub-components. Verifies that they are numerical
@@ -0,0 +1,87 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package system
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const thunderboltDevicesPath = "/sys/bus/thunderbolt/devices"
+
+// Routers, i.e. host controllers and devices, are named <domain>-<route>,
+// the route of the host router being 0. Retimers, XDomain connections and
+// services have longer names.
+var thunderboltRouterRe = regexp.MustCompile(`^[0-9]+-[0-9a-f]+$`)
+
+// Detect Thunderbolt and USB4 controllers, and the devices connected to them
+func detectThunderbolt() (map[string]string, error) {
+	tb := map[string]string{}
+
+	entries, err := ioutil.ReadDir(thunderboltDevicesPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return tb, nil
+		}
+		return nil, err
+	}
+
+	controllers, devices, usb4 := 0, 0, false
+	security := ""
+	for _, entry := range entries {
+		name := entry.Name()
+		devPath := path.Join(thunderboltDevicesPath, name)
+		switch {
+		case strings.HasPrefix(name, "domain"):
+			controllers++
+			// Security level of the first domain, e.g. "user" if
+			// devices need to be authorized by the user
+			if data, err := ioutil.ReadFile(path.Join(devPath, "security")); err == nil && security == "" {
+				security = strings.TrimSpace(string(data))
+			}
+		case thunderboltRouterRe.MatchString(name) && strings.HasSuffix(name, "-0"):
+			// Host router, reporting its generation in Linux v5.15
+			// and later, 4 being USB4
+			if data, err := ioutil.ReadFile(path.Join(devPath, "generation")); err == nil {
+				if gen, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && gen >= 4 {
+					usb4 = true
+				}
+			}
+		case thunderboltRouterRe.MatchString(name):
+			devices++
+		}
+	}
+	if controllers == 0 {
+		return tb, nil
+	}
+
+	tb["present"] = "true"
+	tb["controllers"] = strconv.Itoa(controllers)
+	tb["devices"] = strconv.Itoa(devices)
+	if usb4 {
+		tb["usb4"] = "true"
+	}
+	if security != "" {
+		tb["security"] = security
+	}
+
+	return tb, nil
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package system
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source/internal/labelutils"
+)
+
+const watchdogClassPath = "/sys/class/watchdog"
+
+// Detect watchdog devices, and the driver of the first one, i.e. the one
+// behind /dev/watchdog. The software watchdog of the kernel (softdog) is not
+// considered hardware.
+func detectWatchdog() (map[string]string, error) {
+	wd := map[string]string{}
+
+	devices, err := ioutil.ReadDir(watchdogClassPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return wd, nil
+		}
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return wd, nil
+	}
+
+	hardware := 0
+	driver := ""
+	for i, dev := range devices {
+		devPath := path.Join(watchdogClassPath, dev.Name())
+		name := ""
+		// softdog has no parent device
+		if driverPath, err := filepath.EvalSymlinks(path.Join(devPath, "device", "driver")); err == nil {
+			name = filepath.Base(driverPath)
+			hardware++
+		} else if identity, err := ioutil.ReadFile(path.Join(devPath, "identity")); err == nil {
+			if strings.TrimSpace(string(identity)) == "Software Watchdog" {
+				name = "softdog"
+			} else {
+				name = labelutils.Value(strings.TrimSpace(string(identity)))
+				hardware++
+			}
+		}
+		if i == 0 {
+			driver = name
+		}
+	}
+
+	// The software watchdog alone does not protect against hangs of the
+	// kernel, so present only refers to hardware watchdogs
+	if hardware > 0 {
+		wd["present"] = "true"
+	}
+	wd["count"] = strconv.Itoa(len(devices))
+	wd["hardware"] = strconv.FormatBool(hardware > 0)
+	if driver != "" {
+		wd["driver"] = driver
+	}
+
+	return wd, nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package virtualization
+
+import (
+	"os"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/cpuinfoutils"
+)
+
+// Guest attestation devices of confidential VMs
+var attestationDevices = []string{
+	"/sys/class/misc/sev-guest",
+	"/sys/class/misc/tdx_guest",
+	"/sys/class/misc/tdx-guest",
+}
+
+// detectConfidentialGuest detects if the node is a confidential VM, i.e. its
+// memory is encrypted by the CPU, and if an attestation device is available.
+func detectConfidentialGuest() source.Features {
+	features := source.Features{}
+
+	// In confidential VMs, the kernel reports the memory encryption
+	// technology in use as a CPU flag
+	flags, _ := cpuinfoutils.Flags()
+	guestType := ""
+	switch {
+	case cpuidTdxGuest() || flags["tdx_guest"]:
+		guestType = "tdx"
+	case flags["sev_snp"] || exists("/sys/class/misc/sev-guest"):
+		// The sev-guest device is only provided for SEV-SNP guests
+		guestType = "sev-snp"
+	case flags["sev_es"]:
+		guestType = "sev-es"
+	case flags["sev"]:
+		guestType = "sev"
+	}
+	if guestType == "" {
+		return features
+	}
+
+	features["confidential.enabled"] = true
+	features["confidential.type"] = guestType
+	for _, dev := range attestationDevices {
+		if exists(dev) {
+			features["confidential.attestation"] = true
+			break
+		}
+	}
+	return features
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
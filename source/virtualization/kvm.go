@@ -0,0 +1,199 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package virtualization
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+	"sigs.k8s.io/node-feature-discovery/source/internal/cpuinfoutils"
+)
+
+// Parameters of the KVM vendor modules enabling nested virtualization
+var kvmNestedParams = []string{
+	"/sys/module/kvm_intel/parameters/nested",
+	"/sys/module/kvm_amd/parameters/nested",
+}
+
+// Names of Intel CPU models (family 6) in libvirt, as used by the KubeVirt CPU
+// model node labeller
+var intelCpuModels = map[int]string{
+	0x1a: "Nehalem",
+	0x1e: "Nehalem",
+	0x1f: "Nehalem",
+	0x2e: "Nehalem",
+	0x25: "Westmere",
+	0x2c: "Westmere",
+	0x2f: "Westmere",
+	0x2a: "SandyBridge",
+	0x2d: "SandyBridge",
+	0x3a: "IvyBridge",
+	0x3e: "IvyBridge",
+	0x3c: "Haswell",
+	0x3f: "Haswell",
+	0x45: "Haswell",
+	0x46: "Haswell",
+	0x3d: "Broadwell",
+	0x47: "Broadwell",
+	0x4f: "Broadwell",
+	0x56: "Broadwell",
+	0x4e: "Skylake-Client",
+	0x5e: "Skylake-Client",
+	0x8e: "Skylake-Client",
+	0x9e: "Skylake-Client",
+	// Skylake, Cascade Lake and Cooper Lake servers, told apart by stepping
+	0x55: "Skylake-Server",
+	0x6a: "Icelake-Server",
+	0x6c: "Icelake-Server",
+	0x8f: "SapphireRapids",
+	// Emerald Rapids has no CPU model of its own in libvirt
+	0xcf: "SapphireRapids",
+	0xad: "GraniteRapids",
+	0xae: "GraniteRapids",
+}
+
+// Names of AMD CPU models in libvirt, by family and range of models
+var amdCpuModels = []struct {
+	family, first, last int
+	name                string
+}{
+	{0x17, 0x00, 0x2f, "EPYC"},
+	{0x17, 0x30, 0x3f, "EPYC-Rome"},
+	{0x19, 0x00, 0x0f, "EPYC-Milan"},
+	{0x19, 0x10, 0x1f, "EPYC-Genoa"},
+	{0x19, 0xa0, 0xaf, "EPYC-Genoa"},
+}
+
+// CPU flags of /proc/cpuinfo relevant for VMs, mapped to their names in
+// libvirt
+var libvirtCpuFeatures = map[string]string{
+	"adx":                "adx",
+	"aes":                "aes",
+	"amx_bf16":           "amx-bf16",
+	"amx_int8":           "amx-int8",
+	"amx_tile":           "amx-tile",
+	"arch_capabilities":  "arch-capabilities",
+	"avx":                "avx",
+	"avx2":               "avx2",
+	"avx512_bf16":        "avx512-bf16",
+	"avx512_vnni":        "avx512-vnni",
+	"avx512bw":           "avx512bw",
+	"avx512cd":           "avx512cd",
+	"avx512dq":           "avx512dq",
+	"avx512f":            "avx512f",
+	"avx512vl":           "avx512vl",
+	"bmi1":               "bmi1",
+	"bmi2":               "bmi2",
+	"clflushopt":         "clflushopt",
+	"clwb":               "clwb",
+	"erms":               "erms",
+	"f16c":               "f16c",
+	"fma":                "fma",
+	"gfni":               "gfni",
+	"invpcid":            "invpcid",
+	"la57":               "la57",
+	"md_clear":           "md-clear",
+	"movbe":              "movbe",
+	"pclmulqdq":          "pclmuldq",
+	"pdpe1gb":            "pdpe1gb",
+	"pku":                "pku",
+	"rdrand":             "rdrand",
+	"rdseed":             "rdseed",
+	"sha_ni":             "sha-ni",
+	"ssbd":               "ssbd",
+	"sse4_1":             "sse4.1",
+	"sse4_2":             "sse4.2",
+	"ssse3":              "ssse3",
+	"svm":                "svm",
+	"tsc_deadline_timer": "tsc-deadline",
+	"umip":               "umip",
+	"vaes":               "vaes",
+	"vmx":                "vmx",
+	"vpclmulqdq":         "vpclmulqdq",
+	"x2apic":             "x2apic",
+	"xsave":              "xsave",
+	"xsavec":             "xsavec",
+	"xsaveopt":           "xsaveopt",
+}
+
+// detectKvm detects if KVM is available, and the vendor, model and flags of
+// the host CPU, as boolean features named like the labels of the KubeVirt CPU
+// model node labeller, e.g. kvm.cpu-model.Skylake-Server
+func detectKvm() source.Features {
+	features := source.Features{}
+
+	// The misc device is registered when a KVM vendor module is loaded. The
+	// device node itself isn't checked, /dev being that of the container.
+	if !exists("/sys/class/misc/kvm") {
+		return features
+	}
+	features["kvm.enabled"] = true
+
+	cpu, err := cpuinfoutils.ReadFirstCPU()
+	if err != nil {
+		return features
+	}
+	family, _ := strconv.Atoi(cpu["cpu family"])
+	model, _ := strconv.Atoi(cpu["model"])
+	stepping, _ := strconv.Atoi(cpu["stepping"])
+	switch cpu["vendor_id"] {
+	case "GenuineIntel":
+		features["kvm.cpu-vendor.Intel"] = true
+		if name, ok := intelCpuModels[model]; ok && family == 6 {
+			if model == 0x55 && stepping >= 10 {
+				name = "Cooperlake"
+			} else if model == 0x55 && stepping >= 5 {
+				name = "Cascadelake-Server"
+			}
+			features["kvm.cpu-model."+name] = true
+		}
+	case "AuthenticAMD":
+		features["kvm.cpu-vendor.AMD"] = true
+		for _, m := range amdCpuModels {
+			if family == m.family && model >= m.first && model <= m.last {
+				features["kvm.cpu-model."+m.name] = true
+				break
+			}
+		}
+	}
+
+	for flag := range cpu.Flags() {
+		if name, ok := libvirtCpuFeatures[flag]; ok {
+			features["kvm.cpu-feature."+name] = true
+		}
+	}
+
+	return features
+}
+
+// Check if KVM allows running hypervisors inside guests
+func kvmNested() bool {
+	for _, p := range kvmNestedParams {
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		// kvm_intel reports Y/N, older kvm_amd versions 1/0
+		switch strings.TrimSpace(string(data)) {
+		case "Y", "1":
+			return true
+		}
+	}
+	return false
+}
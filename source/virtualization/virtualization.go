@@ -0,0 +1,104 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package virtualization
+
+import (
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+const dmiPath = "/sys/class/dmi/id"
+
+// DMI system vendors of known hypervisors
+var dmiVendors = map[string]string{
+	"QEMU":                                  "kvm",
+	"VMware, Inc.":                          "vmware",
+	"Microsoft Corporation":                 "hyperv",
+	"Xen":                                   "xen",
+	"innotek GmbH":                          "virtualbox",
+	"Parallels Software International Inc.": "parallels",
+}
+
+// Implement FeatureSource interface
+type Source struct{}
+
+func (s Source) Name() string { return "virtualization" }
+
+func (s Source) Discover() (source.Features, error) {
+	features := source.Features{}
+
+	hv := detectHypervisor()
+	features["type"] = hv
+
+	if hv != "none" {
+		for k, v := range detectConfidentialGuest() {
+			features[k] = v
+		}
+	}
+
+	if kvmNested() {
+		features["nested"] = true
+	}
+
+	for k, v := range detectKvm() {
+		features[k] = v
+	}
+
+	return features, nil
+}
+
+// Detect the hypervisor the node is running on, "none" on bare-metal
+func detectHypervisor() string {
+	sysVendor := readDmi("sys_vendor")
+
+	// The cpuid hypervisor leaf is the most reliable source of information
+	hv := cpuidHypervisor()
+	if hv == "" {
+		// Xen PV guests and non-x86 platforms
+		if data, err := ioutil.ReadFile("/sys/hypervisor/type"); err == nil {
+			hv = strings.TrimSpace(string(data))
+		}
+	}
+	if hv == "" {
+		hv = dmiVendors[sysVendor]
+		// Hyper-V hosts have the same vendor as the guests
+		if hv == "hyperv" && readDmi("product_name") != "Virtual Machine" {
+			hv = ""
+		}
+	}
+
+	switch {
+	case hv == "":
+		return "none"
+	case sysVendor == "Amazon EC2" && hv == "kvm":
+		// EC2 Nitro is based on KVM, bare-metal instances have no hypervisor
+		return "nitro"
+	}
+	return hv
+}
+
+// Read one DMI attribute
+func readDmi(attr string) string {
+	data, err := ioutil.ReadFile(path.Join(dmiPath, attr))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
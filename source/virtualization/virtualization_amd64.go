@@ -0,0 +1,82 @@
+// +build amd64
+
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package virtualization
+
+import (
+	"encoding/binary"
+
+	"sigs.k8s.io/node-feature-discovery/source/internal/cpuidutils"
+)
+
+const (
+	// CPUID EAX input values
+	LEAF_BASIC_INFO    = 0x00
+	LEAF_FEATURE_FLAGS = 0x01
+	LEAF_TDX_GUEST     = 0x21
+	LEAF_HYPERVISOR    = 0x40000000
+
+	// CPUID bitmasks
+	FEATURE_FLAGS_ECX_HYPERVISOR = 1 << 31
+)
+
+// Hypervisor vendor signatures reported by cpuid
+var cpuidSignatures = map[string]string{
+	"KVMKVMKVM\x00\x00\x00": "kvm",
+	"VMwareVMware":          "vmware",
+	"Microsoft Hv":          "hyperv",
+	"XenVMMXenVMM":          "xen",
+	"TCGTCGTCGTCG":          "qemu",
+	"VBoxVBoxVBox":          "virtualbox",
+	"bhyve bhyve ":          "bhyve",
+	"ACRNACRNACRN":          "acrn",
+	" lrpepyh  vr":          "parallels",
+}
+
+// Get the hypervisor from the cpuid hypervisor leaf
+func cpuidHypervisor() string {
+	if cpuidutils.Cpuid(LEAF_FEATURE_FLAGS, 0).ECX&FEATURE_FLAGS_ECX_HYPERVISOR == 0 {
+		return ""
+	}
+
+	r := cpuidutils.Cpuid(LEAF_HYPERVISOR, 0)
+	sig := make([]byte, 12)
+	binary.LittleEndian.PutUint32(sig[0:], r.EBX)
+	binary.LittleEndian.PutUint32(sig[4:], r.ECX)
+	binary.LittleEndian.PutUint32(sig[8:], r.EDX)
+
+	if hv, ok := cpuidSignatures[string(sig)]; ok {
+		return hv
+	}
+	return "other"
+}
+
+// Check if running in an Intel TDX trust domain, from the cpuid TDX leaf
+func cpuidTdxGuest() bool {
+	if cpuidutils.Cpuid(LEAF_BASIC_INFO, 0).EAX < LEAF_TDX_GUEST {
+		return false
+	}
+
+	r := cpuidutils.Cpuid(LEAF_TDX_GUEST, 0)
+	sig := make([]byte, 12)
+	binary.LittleEndian.PutUint32(sig[0:], r.EBX)
+	binary.LittleEndian.PutUint32(sig[4:], r.EDX)
+	binary.LittleEndian.PutUint32(sig[8:], r.ECX)
+
+	return string(sig) == "IntelTDX    "
+}